@@ -16,35 +16,218 @@ package hiro
 
 import (
 	"context"
+	"slices"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+var (
+	ErrAchievementsMetaCycle   = runtime.NewError("meta achievement cycle", 3)   // INVALID_ARGUMENT
+	ErrAchievementsMetaUnknown = runtime.NewError("meta achievement unknown", 3) // INVALID_ARGUMENT
+)
+
+// The reserved additional properties set on achievements which have a daily progress cap.
+const (
+	// AchievementPropertyDailyCapClamped is the amount of progress discarded by the daily cap in the latest update.
+	AchievementPropertyDailyCapClamped = "hiro_daily_cap_clamped"
+	// AchievementPropertyDailyCapRemaining is the amount of progress which can still be made before the cap resets.
+	AchievementPropertyDailyCapRemaining = "hiro_daily_cap_remaining"
+)
+
+// AchievementPropertyConsecutiveCounted is the reserved additional property set on consecutive achievements to "true"
+// if progress has already been counted in the current period, and "false" otherwise.
+const AchievementPropertyConsecutiveCounted = "hiro_consecutive_counted"
+
+// AchievementProgressMilestoneEventName is the name of the event published when progress on an achievement first
+// crosses one of its milestones. Its metadata holds the achievement ID and the milestone percentage.
+const AchievementProgressMilestoneEventName = "achievementProgressMilestone"
+
+// The metadata keys set on achievement progress milestone events.
+const (
+	AchievementProgressMilestoneMetadataId        = "achievement_id"
+	AchievementProgressMilestoneMetadataMilestone = "milestone"
+)
+
 // AchievementsConfig is the data definition for the TutorialsSystem type.
 type AchievementsConfig struct {
 	Achievements map[string]*AchievementsConfigAchievement `json:"achievements,omitempty"`
 }
 
 type AchievementsConfigAchievement struct {
-	AutoClaim            bool                                         `json:"auto_claim,omitempty"`
-	AutoClaimTotal       bool                                         `json:"auto_claim_total,omitempty"`
-	AutoReset            bool                                         `json:"auto_reset,omitempty"`
-	Category             string                                       `json:"category,omitempty"`
-	Count                int64                                        `json:"count,omitempty"`
-	Description          string                                       `json:"description,omitempty"`
-	StartTimeSec         int64                                        `json:"start_time_sec,omitempty"`
-	EndTimeSec           int64                                        `json:"end_time_sec,omitempty"`
-	ResetCronexpr        string                                       `json:"reset_cronexpr,omitempty"`
-	DurationSec          int64                                        `json:"duration_sec,omitempty"`
-	MaxCount             int64                                        `json:"max_count,omitempty"`
+	AutoClaim      bool                           `json:"auto_claim,omitempty"`
+	AutoClaimTotal bool                           `json:"auto_claim_total,omitempty"`
+	AutoReset      bool                           `json:"auto_reset,omitempty"`
+	Category       string                         `json:"category,omitempty"`
+	Consecutive    *AchievementsConfigConsecutive `json:"consecutive,omitempty"`
+	Count          int64                          `json:"count,omitempty"`
+	DailyCap       *AchievementsConfigDailyCap    `json:"daily_cap,omitempty"`
+	Description    string                         `json:"description,omitempty"`
+	StartTimeSec   int64                          `json:"start_time_sec,omitempty"`
+	EndTimeSec     int64                          `json:"end_time_sec,omitempty"`
+	ResetCronexpr  string                         `json:"reset_cronexpr,omitempty"`
+	DurationSec    int64                          `json:"duration_sec,omitempty"`
+	MaxCount       int64                          `json:"max_count,omitempty"`
+	// Percentages of the count, such as 25, 50, and 75, at which a progress milestone event is published.
+	Milestones           []int                                        `json:"milestones,omitempty"`
+	Meta                 *AchievementsConfigMeta                      `json:"meta,omitempty"`
 	Name                 string                                       `json:"name,omitempty"`
 	PreconditionIDs      []string                                     `json:"precondition_ids,omitempty"`
 	Reward               *EconomyConfigReward                         `json:"reward,omitempty"`
+	Secret               bool                                         `json:"secret,omitempty"`
 	TotalReward          *EconomyConfigReward                         `json:"total_reward,omitempty"`
 	SubAchievements      map[string]*AchievementsConfigSubAchievement `json:"sub_achievements,omitempty"`
 	AdditionalProperties map[string]string                            `json:"additional_properties,omitempty"`
 }
 
+// AchievementsConfigDailyCap limits how much progress can be made on an achievement each day. The cap resets at the
+// day boundary independently of the achievement's own reset schedule.
+type AchievementsConfigDailyCap struct {
+	Max int64 `json:"max,omitempty"`
+	// If true the day boundary is computed in the user's pinned timezone instead of UTC.
+	UserTimezone bool `json:"user_timezone,omitempty"`
+}
+
+// AchievementsConfigConsecutive makes an achievement count consecutive periods, such as logging in 7 days in a row.
+// Progress increments at most once per period however many updates are made, and a missed period resets progress to
+// zero on the next evaluation. The grace options mirror the idle count decay of the StreaksSystem.
+type AchievementsConfigConsecutive struct {
+	// The schedule of period boundaries, defaults to daily at midnight.
+	ResetCronexpr string `json:"reset_cronexpr,omitempty"`
+	// The number of periods which can be missed before progress is reset.
+	GracePeriods int64 `json:"grace_periods,omitempty"`
+	// If true progress is reduced by one for each period missed within the grace periods, instead of being kept.
+	GraceDecay bool `json:"grace_decay,omitempty"`
+	// If true the period boundaries are computed in the user's pinned timezone instead of UTC.
+	UserTimezone bool `json:"user_timezone,omitempty"`
+}
+
+// Advance returns the consecutive progress after an update in the given period, where periods are the indexes of the
+// boundaries of the reset schedule and lastPeriod is the period of the last counted update. Counted is false if
+// progress was already counted in the period, in which case count is returned unchanged.
+func (c *AchievementsConfigConsecutive) Advance(count, lastPeriod, period int64) (newCount int64, counted bool) {
+	if c == nil {
+		return count + 1, true
+	}
+	if count > 0 && period <= lastPeriod {
+		return count, false
+	}
+	if count <= 0 {
+		return 1, true
+	}
+
+	missed := period - lastPeriod - 1
+	switch {
+	case missed <= 0:
+	case missed > c.GracePeriods:
+		count = 0
+	case c.GraceDecay:
+		count = max(0, count-missed)
+	}
+	return count + 1, true
+}
+
+// AchievementsConfigMeta makes an achievement a meta-achievement whose progress is the number of member achievements
+// the user has completed, towards its count. Members are matched by category, explicit ID, or both. Progress is
+// updated when a member completes, and is computed from the members' completion on first read so users who completed
+// members before the meta-achievement was added are backfilled. Resets follow the meta-achievement's own schedule.
+type AchievementsConfigMeta struct {
+	Categories     []string `json:"categories,omitempty"`
+	AchievementIDs []string `json:"achievement_ids,omitempty"`
+}
+
+// MetaMembers returns the IDs of the achievements counted towards a meta-achievement, sorted. The meta-achievement is
+// never a member of itself.
+func (c *AchievementsConfig) MetaMembers(achievementID string) []string {
+	achievement, found := c.Achievements[achievementID]
+	if !found || achievement == nil || achievement.Meta == nil {
+		return nil
+	}
+
+	members := make([]string, 0, len(achievement.Meta.AchievementIDs))
+	for id, member := range c.Achievements {
+		if member == nil || id == achievementID {
+			continue
+		}
+		if slices.Contains(achievement.Meta.AchievementIDs, id) || (member.Category != "" && slices.Contains(achievement.Meta.Categories, member.Category)) {
+			members = append(members, id)
+		}
+	}
+	slices.Sort(members)
+	return members
+}
+
+// ValidateMeta checks the meta-achievements in the config. It returns ErrAchievementsMetaUnknown if a meta-achievement
+// references an achievement which does not exist, and ErrAchievementsMetaCycle if meta-achievements count each other
+// directly or indirectly.
+func (c *AchievementsConfig) ValidateMeta() error {
+	for _, achievement := range c.Achievements {
+		if achievement == nil || achievement.Meta == nil {
+			continue
+		}
+		for _, id := range achievement.Meta.AchievementIDs {
+			if member, found := c.Achievements[id]; !found || member == nil {
+				return ErrAchievementsMetaUnknown
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(c.Achievements))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return ErrAchievementsMetaCycle
+		case visited:
+			return nil
+		}
+		state[id] = visiting
+		for _, member := range c.MetaMembers(id) {
+			if c.Achievements[member].Meta == nil {
+				continue
+			}
+			if err := visit(member); err != nil {
+				return err
+			}
+		}
+		state[id] = visited
+		return nil
+	}
+	for id := range c.Achievements {
+		if err := visit(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MilestonesCrossed returns the milestones, in ascending order, which progress crossed in an update from the previous
+// count to the new count. Each milestone is only crossed once per reset, so an update which advances past several
+// milestones returns each of them and later updates do not return them again.
+func (c *AchievementsConfigAchievement) MilestonesCrossed(previousCount, count int64) []int {
+	if c == nil || c.Count <= 0 || count <= previousCount {
+		return nil
+	}
+
+	var crossed []int
+	for _, milestone := range c.Milestones {
+		if milestone <= 0 || milestone > 100 || slices.Contains(crossed, milestone) {
+			continue
+		}
+		// Round the threshold up so a milestone is never reported before the percentage is reached.
+		threshold := (c.Count*int64(milestone) + 99) / 100
+		if previousCount < threshold && count >= threshold {
+			crossed = append(crossed, milestone)
+		}
+	}
+	slices.Sort(crossed)
+	return crossed
+}
+
 type AchievementsConfigSubAchievement struct {
 	AutoClaim            bool                 `json:"auto_claim,omitempty"`
 	AutoReset            bool                 `json:"auto_reset,omitempty"`
@@ -60,6 +243,24 @@ type AchievementsConfigSubAchievement struct {
 	AdditionalProperties map[string]string    `json:"additional_properties,omitempty"`
 }
 
+// AchievementsClaimError is returned when claiming several achievements stops partway, for example when a reward
+// cannot be granted due to inventory capacity. Achievements claimed before the failure remain claimed.
+type AchievementsClaimError struct {
+	// The achievement which failed to be claimed.
+	AchievementID string
+	// The achievements which were claimed before the failure.
+	ClaimedIDs []string
+	Err        error
+}
+
+func (e *AchievementsClaimError) Error() string {
+	return "achievement claim failed at " + e.AchievementID + ": " + e.Err.Error()
+}
+
+func (e *AchievementsClaimError) Unwrap() error {
+	return e.Err
+}
+
 // An AchievementsSystem is a gameplay system which represents one-off, repeat, preconditioned, and sub-achievements.
 type AchievementsSystem interface {
 	System
@@ -67,12 +268,29 @@ type AchievementsSystem interface {
 	// ClaimAchievements when one or more achievements whose progress has completed by their IDs.
 	ClaimAchievements(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, achievementIDs []string, claimTotal bool) (achievements map[string]*Achievement, repeatAchievements map[string]*Achievement, err error)
 
-	// GetAchievements returns all achievements available to the user and progress on them.
+	// ClaimAllAchievements claims every completed and unclaimed achievement, and their sub-achievements, optionally
+	// only within the given category. The rewards granted are returned grouped by achievement ID. If a claim fails
+	// an *AchievementsClaimError reports which achievement it stopped at.
+	ClaimAllAchievements(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, category string) (achievements map[string]*Achievement, repeatAchievements map[string]*Achievement, rewards map[string]*Reward, err error)
+
+	// GetAchievements returns all achievements available to the user and progress on them. The name and description of
+	// secret achievements are replaced with placeholders until they are completed.
 	GetAchievements(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (achievements map[string]*Achievement, repeatAchievements map[string]*Achievement, err error)
 
-	// UpdateAchievements updates progress on one or more achievements by the same amount.
+	// DebugGetAchievements returns all achievements available to the user and progress on them, without masking the
+	// details of secret achievements which have not been completed yet.
+	DebugGetAchievements(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (achievements map[string]*Achievement, repeatAchievements map[string]*Achievement, err error)
+
+	// UpdateAchievements updates progress on one or more achievements by the same amount. Progress beyond an
+	// achievement's daily cap is clamped, and the clamped amount is reported in its additional properties. Updates
+	// made with a context tagged by WithTransaction are recorded so they can be rolled back. Consecutive achievements
+	// are advanced at most once per period, and report whether the current period was already counted.
 	UpdateAchievements(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, achievementUpdates map[string]int64) (achievements map[string]*Achievement, repeatAchievements map[string]*Achievement, err error)
 
+	// RollbackTransaction reverses the achievement progress made for the user in a transaction tagged with
+	// WithTransaction. Rewards which have already been claimed are not reversed.
+	RollbackTransaction(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, transactionID string) (achievements map[string]*Achievement, repeatAchievements map[string]*Achievement, contributions []*TransactionContribution, err error)
+
 	// SetOnAchievementReward sets a custom reward function which will run after an achievement's reward is rolled.
 	SetOnAchievementReward(fn OnReward[*AchievementsConfigAchievement])
 
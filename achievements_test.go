@@ -0,0 +1,96 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestAchievementsConfigMetaMembers(t *testing.T) {
+	config := &AchievementsConfig{
+		Achievements: map[string]*AchievementsConfigAchievement{
+			"collector": {Meta: &AchievementsConfigMeta{Categories: []string{"combat"}, AchievementIDs: []string{"explorer"}}, Category: "combat"},
+			"slayer":    {Category: "combat"},
+			"survivor":  {Category: "combat"},
+			"explorer":  {Category: "travel"},
+			"trader":    {Category: "economy"},
+			"removed":   nil,
+		},
+	}
+
+	if members := config.MetaMembers("collector"); !slices.Equal(members, []string{"explorer", "slayer", "survivor"}) {
+		t.Errorf("members = %v, want [explorer slayer survivor]", members)
+	}
+	if members := config.MetaMembers("removed"); members != nil {
+		t.Errorf("members of a nil achievement = %v, want none", members)
+	}
+	if err := config.ValidateMeta(); err != nil {
+		t.Errorf("validate failed: %v", err)
+	}
+
+	config.Achievements["collector"].Meta.AchievementIDs = []string{"removed"}
+	if err := config.ValidateMeta(); !errors.Is(err, ErrAchievementsMetaUnknown) {
+		t.Errorf("validate with a nil member returned %v, want %v", err, ErrAchievementsMetaUnknown)
+	}
+
+	config.Achievements["collector"].Meta.AchievementIDs = nil
+	config.Achievements["slayer"].Meta = &AchievementsConfigMeta{AchievementIDs: []string{"collector"}}
+	if err := config.ValidateMeta(); !errors.Is(err, ErrAchievementsMetaCycle) {
+		t.Errorf("validate with a cycle returned %v, want %v", err, ErrAchievementsMetaCycle)
+	}
+}
+
+func TestAchievementsConfigAchievementMilestonesCrossed(t *testing.T) {
+	achievement := &AchievementsConfigAchievement{Count: 200, Milestones: []int{75, 25, 50, 100}}
+
+	tests := []struct {
+		name          string
+		previousCount int64
+		count         int64
+		want          []int
+	}{
+		{name: "none crossed", previousCount: 0, count: 49, want: nil},
+		{name: "one crossed", previousCount: 49, count: 50, want: []int{25}},
+		{name: "several crossed", previousCount: 10, count: 160, want: []int{25, 50, 75}},
+		{name: "repeated update", previousCount: 160, count: 160, want: nil},
+		{name: "already past", previousCount: 160, count: 170, want: nil},
+		{name: "all crossed", previousCount: 0, count: 500, want: []int{25, 50, 75, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if crossed := achievement.MilestonesCrossed(tt.previousCount, tt.count); !slices.Equal(crossed, tt.want) {
+				t.Errorf("crossed = %v, want %v", crossed, tt.want)
+			}
+		})
+	}
+
+	// Each milestone fires once across a sequence of updates.
+	fired := make(map[int]int)
+	var count int64
+	for _, next := range []int64{30, 120, 120, 150, 150, 210} {
+		for _, milestone := range achievement.MilestonesCrossed(count, next) {
+			fired[milestone]++
+		}
+		count = next
+	}
+	for _, milestone := range achievement.Milestones {
+		if fired[milestone] != 1 {
+			t.Errorf("milestone %d fired %d times, want once", milestone, fired[milestone])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// ActorMetadataKey is the key used to record the actor in wallet ledger metadata and publisher event metadata.
+const ActorMetadataKey = "actor"
+
+// The ActorType identifies who performed an operation on a user.
+type ActorType string
+
+const (
+	// ActorTypeSystem is a server-side operation which was not requested by a user, such as a scheduled job.
+	ActorTypeSystem ActorType = "system"
+	// ActorTypeSelf is an operation requested by the user it applies to.
+	ActorTypeSelf ActorType = "self"
+	// ActorTypeAdmin is an operation performed on behalf of a user by an administrator, such as a support agent.
+	ActorTypeAdmin ActorType = "admin"
+)
+
+// An Actor is who performed an operation, which is recorded for audit purposes alongside the target user.
+type Actor struct {
+	Type ActorType `json:"type,omitempty"`
+	// The identifier of the actor, such as a support agent ID. Not set for system actors.
+	Id string `json:"id,omitempty"`
+}
+
+// String returns the actor in the form recorded in ledger and event metadata, such as "admin:agent-123".
+func (a *Actor) String() string {
+	if a.Id == "" {
+		return string(a.Type)
+	}
+	return string(a.Type) + ":" + a.Id
+}
+
+type actorContextKey struct{}
+
+// WithActor returns a context which attributes the operations performed with it to the given actor. Use this for
+// admin-initiated operations so the agent who performed them is recorded.
+func WithActor(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor set on the context with WithActor. Otherwise the actor is the user in the
+// session for client requests, or the system for server-to-server requests.
+func ActorFromContext(ctx context.Context) *Actor {
+	if actor, ok := ctx.Value(actorContextKey{}).(*Actor); ok && actor != nil {
+		return actor
+	}
+	if userID, ok := ctx.Value(runtime.RUNTIME_CTX_USER_ID).(string); ok && userID != "" {
+		return &Actor{Type: ActorTypeSelf, Id: userID}
+	}
+	return &Actor{Type: ActorTypeSystem}
+}
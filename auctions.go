@@ -100,7 +100,8 @@ type AuctionsSystem interface {
 	// ClaimBid claims a completed auction as the successful bidder.
 	ClaimBid(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, auctionID string) (*AuctionClaimBid, error)
 
-	// ClaimCreated claims a completed auction as the auction creator.
+	// ClaimCreated claims a completed auction as the auction creator. Currencies received are reduced by the fee and
+	// then by any transfer tax configured for the currency, which is recorded in the wallet ledger metadata.
 	ClaimCreated(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, auctionID string) (*AuctionClaimCreated, error)
 
 	// Cancel an active auction before it reaches its scheduled end time.
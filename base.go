@@ -19,6 +19,8 @@ import (
 	"database/sql"
 	"errors"
 	"plugin"
+	"reflect"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/api"
 	"github.com/heroiclabs/nakama-common/runtime"
@@ -26,19 +28,20 @@ import (
 )
 
 var (
-	ErrInternal           = runtime.NewError("internal error occurred", 13) // INTERNAL
-	ErrBadInput           = runtime.NewError("bad input", 3)                // INVALID_ARGUMENT
-	ErrFileNotFound       = runtime.NewError("file not found", 3)
-	ErrNoSessionUser      = runtime.NewError("no user ID in session", 3)       // INVALID_ARGUMENT
-	ErrNoSessionID        = runtime.NewError("no session ID in session", 3)    // INVALID_ARGUMENT
-	ErrNoSessionUsername  = runtime.NewError("no username in session", 3)      // INVALID_ARGUMENT
-	ErrPayloadDecode      = runtime.NewError("cannot decode json", 13)         // INTERNAL
-	ErrPayloadEmpty       = runtime.NewError("payload should not be empty", 3) // INVALID_ARGUMENT
-	ErrPayloadEncode      = runtime.NewError("cannot encode json", 13)         // INTERNAL
-	ErrPayloadInvalid     = runtime.NewError("payload is invalid", 3)          // INVALID_ARGUMENT
-	ErrSessionUser        = runtime.NewError("user ID in session", 3)          // INVALID_ARGUMENT
-	ErrSystemNotAvailable = runtime.NewError("system not available", 13)       // INTERNAL
-	ErrSystemNotFound     = runtime.NewError("system not found", 13)           // INTERNAL
+	ErrInternal            = runtime.NewError("internal error occurred", 13) // INTERNAL
+	ErrBadInput            = runtime.NewError("bad input", 3)                // INVALID_ARGUMENT
+	ErrFileNotFound        = runtime.NewError("file not found", 3)
+	ErrNoSessionUser       = runtime.NewError("no user ID in session", 3)       // INVALID_ARGUMENT
+	ErrNoSessionID         = runtime.NewError("no session ID in session", 3)    // INVALID_ARGUMENT
+	ErrNoSessionUsername   = runtime.NewError("no username in session", 3)      // INVALID_ARGUMENT
+	ErrPayloadDecode       = runtime.NewError("cannot decode json", 13)         // INTERNAL
+	ErrPayloadEmpty        = runtime.NewError("payload should not be empty", 3) // INVALID_ARGUMENT
+	ErrPayloadEncode       = runtime.NewError("cannot encode json", 13)         // INTERNAL
+	ErrPayloadInvalid      = runtime.NewError("payload is invalid", 3)          // INVALID_ARGUMENT
+	ErrSessionUser         = runtime.NewError("user ID in session", 3)          // INVALID_ARGUMENT
+	ErrSystemNotAvailable  = runtime.NewError("system not available", 13)       // INTERNAL
+	ErrSystemNotFound      = runtime.NewError("system not found", 13)           // INTERNAL
+	ErrSystemNotConfigured = runtime.NewError("system not configured", 9)       // FAILED_PRECONDITION
 )
 
 // The BaseSystem provides various small features which aren't large enough to be in their own gameplay systems.
@@ -53,6 +56,19 @@ type BaseSystem interface {
 
 	// Sync processes an operation to update the server with offline state changes.
 	Sync(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, req *SyncRequest) (resp *SyncResponse, err error)
+
+	// ReplayResync issues a single-use token which lets the client restart its replay protection sequence for an RPC,
+	// such as after a reinstall. The token is included with the next request to the RPC.
+	ReplayResync(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, rpcID string) (resyncToken string, err error)
+
+	// FirstSeen returns when the user was first seen by Hiro. The timestamp is created lazily with the current time if
+	// it has not been recorded yet, and never changes afterwards.
+	FirstSeen(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (firstSeenTimeSec int64, err error)
+
+	// DailyLoginGrant returns whether today's daily login grant has already been given to the user, and when the next
+	// day starts. The grant itself is applied on the user's first login of the day, and the reward is only returned
+	// from the call which gave it.
+	DailyLoginGrant(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (given bool, reward *Reward, nextResetTimeSec int64, err error)
 }
 
 // BaseSystemConfig is the data definition for the BaseSystem type.
@@ -67,6 +83,15 @@ type BaseSystemConfig struct {
 	RateAppSmtpPort          int    `json:"rate_app_smtp_port,omitempty"`            // 587
 
 	RateAppTemplate string `json:"rate_app_template"` // HTML email template
+
+	// Requests which take longer than the threshold log a breakdown of the time spent in each phase, zero disables it.
+	SlowOperationThresholdMs int64 `json:"slow_operation_threshold_ms,omitempty"`
+
+	Experiments map[string]*BaseSystemConfigExperiment `json:"experiments,omitempty"`
+
+	ReplayProtection *BaseSystemConfigReplayProtection `json:"replay_protection,omitempty"`
+
+	DailyLoginGrant *BaseSystemConfigDailyLoginGrant `json:"daily_login_grant,omitempty"`
 }
 
 type AfterAuthenticateFn func(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, session *api.Session) error
@@ -81,6 +106,16 @@ type Hiro interface {
 
 	AddPublisher(publisher Publisher)
 
+	// AddNotifier registers a notifier which is called after rewards are granted to a user.
+	AddNotifier(notifier Notifier)
+
+	// SetProfileLocker sets the lock used to serialize whole-profile operations with normal mutations for the same user.
+	SetProfileLocker(locker ProfileLocker)
+
+	// SetIdempotencyStore sets the store of grant-once and replay protection markers, which defaults to a
+	// StorageIdempotencyStore so markers survive restarts.
+	SetIdempotencyStore(store IdempotencyStore)
+
 	SetAfterAuthenticate(fn AfterAuthenticateFn)
 
 	// SetCollectionResolver sets a function that may change the storage collection target for Hiro systems. Not typically used.
@@ -101,6 +136,48 @@ type Hiro interface {
 	GetIncentivesSystem() IncentivesSystem
 	GetAuctionsSystem() AuctionsSystem
 	GetStreaksSystem() StreaksSystem
+
+	// GetCooldowns returns the cooldowns configured in the economy system.
+	GetCooldowns() Cooldowns
+
+	// RollbackTransaction reverses the progress contributions made for the user across the stats and achievements
+	// systems in a transaction tagged with WithTransaction.
+	RollbackTransaction(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, transactionID string) (contributions []*TransactionContribution, err error)
+
+	// ComputeOfflineProgress grants the currencies the user accrued since they were last seen, as configured in the
+	// economy system's offline progress, and records them as seen now. It's intended to be called on login.
+	ComputeOfflineProgress(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (progress *EconomyOfflineProgress, err error)
+
+	// GetLastInteractions returns when the user last claimed from and interacted with each object in the claimable
+	// gameplay systems, optionally only for one system type. The same times are set as additional properties on the
+	// objects returned by each system.
+	GetLastInteractions(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, systemType SystemType) (interactions []*LastInteraction, err error)
+
+	// GetExperiment returns the user's bucket in an experiment configured in the base system. The bucket is assigned
+	// and persisted on the first evaluation, and reused afterwards, as by BaseSystemConfigExperiment.Evaluate.
+	GetExperiment(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, experimentID string) (experiment *Experiment, err error)
+
+	// SetExperimentBucket forces the user's bucket in an experiment, such as for QA. An empty bucket clears the override
+	// so the user is assigned again on their next evaluation.
+	SetExperimentBucket(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, experimentID, bucket string) (experiment *Experiment, err error)
+
+	// Systems returns the types of the gameplay systems which have been registered with this Hiro instance.
+	Systems() []SystemType
+
+	// SystemsInfo returns details of each gameplay system which has been registered with this Hiro instance.
+	SystemsInfo() []*SystemInfo
+}
+
+// SystemInfo describes a gameplay system registered with a Hiro instance.
+type SystemInfo struct {
+	Type SystemType `json:"type"`
+	// Enabled is true if the system was initialized successfully and is available.
+	Enabled bool `json:"enabled"`
+	// Register is true if the system's RPCs are registered with the game server.
+	Register   bool   `json:"register"`
+	ConfigFile string `json:"config_file,omitempty"`
+	// ConfigVersion is a content hash of the base configuration loaded for the system.
+	ConfigVersion string `json:"config_version,omitempty"`
 }
 
 // The SystemType identifies each of the gameplay systems.
@@ -125,8 +202,52 @@ const (
 	SystemTypeStreaks
 )
 
+// String returns the name of the gameplay system type.
+func (s SystemType) String() string {
+	switch s {
+	case SystemTypeBase:
+		return "base"
+	case SystemTypeEnergy:
+		return "energy"
+	case SystemTypeUnlockables:
+		return "unlockables"
+	case SystemTypeTutorials:
+		return "tutorials"
+	case SystemTypeLeaderboards:
+		return "leaderboards"
+	case SystemTypeStats:
+		return "stats"
+	case SystemTypeTeams:
+		return "teams"
+	case SystemTypeInventory:
+		return "inventory"
+	case SystemTypeAchievements:
+		return "achievements"
+	case SystemTypeEconomy:
+		return "economy"
+	case SystemTypeEventLeaderboards:
+		return "event_leaderboards"
+	case SystemTypeProgression:
+		return "progression"
+	case SystemTypeIncentives:
+		return "incentives"
+	case SystemTypeAuctions:
+		return "auctions"
+	case SystemTypeStreaks:
+		return "streaks"
+	default:
+		return "unknown"
+	}
+}
+
 // Init initializes a Hiro type with the configurations provided.
 func Init(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, initializer runtime.Initializer, binPath string, licenseKey string, configs ...SystemConfig) (Hiro, error) {
+	// Initialize systems after the systems they depend on, invalid dependencies fail before the plugin is loaded.
+	configs, err := SystemInitOrder(configs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Open the plugin.
 	binFile, err := nk.ReadFile(binPath)
 	if err != nil {
@@ -200,6 +321,69 @@ func (sc *systemConfig) GetExtra() any {
 	return sc.extra
 }
 
+// systemConfigWrapper is implemented by the configs returned by options such as WithStateCache, which wrap another
+// system config.
+type systemConfigWrapper interface {
+	unwrap() SystemConfig
+}
+
+// systemConfigAs returns the first config in the chain of wrapped configs which implements T, so optional interfaces
+// are found whatever order options are applied in.
+func systemConfigAs[T any](config SystemConfig) (T, bool) {
+	for config != nil {
+		if t, ok := config.(T); ok {
+			return t, true
+		}
+		wrapper, ok := config.(systemConfigWrapper)
+		if !ok {
+			break
+		}
+		config = wrapper.unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// SystemConfigStateCache is an optional interface implemented by system configs which enable the user state cache,
+// see WithStateCache.
+type SystemConfigStateCache interface {
+	// GetStateCacheTTL returns how long user state read by the gameplay system may be cached, zero disables the cache.
+	GetStateCacheTTL() time.Duration
+}
+
+// GetStateCacheTTL returns how long user state read by the gameplay system may be cached, or zero if the system
+// config does not enable the cache.
+func GetStateCacheTTL(config SystemConfig) time.Duration {
+	if sc, ok := systemConfigAs[SystemConfigStateCache](config); ok {
+		return sc.GetStateCacheTTL()
+	}
+	return 0
+}
+
+type stateCacheSystemConfig struct {
+	SystemConfig
+	ttl time.Duration
+}
+
+func (sc *stateCacheSystemConfig) GetStateCacheTTL() time.Duration {
+	return sc.ttl
+}
+func (sc *stateCacheSystemConfig) unwrap() SystemConfig {
+	return sc.SystemConfig
+}
+
+// WithStateCache enables an in-memory read-through cache of user state for a gameplay system, for read-heavy systems
+// such as achievements which are listed on every screen. Cached state is used for up to the TTL, and is invalidated
+// by any write the system makes to the user's state.
+//
+// Writes made to the user's storage objects outside of the system are not seen until the TTL expires.
+func WithStateCache(config SystemConfig, ttl time.Duration) SystemConfig {
+	return &stateCacheSystemConfig{
+		SystemConfig: config,
+		ttl:          ttl,
+	}
+}
+
 // OnReward is a function which can be used by each gameplay system to provide an override reward.
 type OnReward[T any] func(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, sourceID string, source T, rewardConfig *EconomyConfigReward, reward *Reward) (*Reward, error)
 
@@ -212,6 +396,24 @@ type System interface {
 	GetConfig() any
 }
 
+// GetSystemConfig returns the configuration of a gameplay system as the given type. If the system has no resolvable
+// configuration, such as when its definitions file and personalization are both missing, ErrSystemNotConfigured is
+// returned. System entry points return this error rather than failing further into a call.
+func GetSystemConfig[T any](system System) (T, error) {
+	var zero T
+	if system == nil {
+		return zero, ErrSystemNotConfigured
+	}
+	config, ok := system.GetConfig().(T)
+	if !ok {
+		return zero, ErrSystemNotConfigured
+	}
+	if v := reflect.ValueOf(config); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return zero, ErrSystemNotConfigured
+	}
+	return config, nil
+}
+
 // UsernameOverrideFn can be used to provide a different username generation strategy from the default in Nakama server.
 // Requested username indicates what the username would otherwise be set to, if the incoming request specified a value.
 // The function is always expected to return a value, and returning "" defers to Nakama's built-in behaviour.
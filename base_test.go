@@ -0,0 +1,83 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestGetStateCacheTTL only checks the TTL is passed through the system config options. The cache itself, including
+// its invalidation after a mutation, lives in the gameplay system implementations and is not covered here.
+func TestGetStateCacheTTL(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SystemConfig
+		want   time.Duration
+	}{
+		{
+			name:   "disabled",
+			config: WithAchievementsSystem("achievements.json", true),
+		},
+		{
+			name:   "enabled",
+			config: WithStateCache(WithAchievementsSystem("achievements.json", true), time.Minute),
+			want:   time.Minute,
+		},
+		{
+			name:   "wrapped",
+			config: WithDependencies(WithStateCache(WithAchievementsSystem("achievements.json", true), time.Minute), SystemTypeStats),
+			want:   time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ttl := GetStateCacheTTL(tt.config); ttl != tt.want {
+				t.Errorf("state cache TTL = %v, want %v", ttl, tt.want)
+			}
+			if tt.config.GetType() != SystemTypeAchievements {
+				t.Errorf("system type = %v, want %v", tt.config.GetType(), SystemTypeAchievements)
+			}
+		})
+	}
+}
+
+func TestGetSystemConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		system  System
+		wantErr error
+	}{
+		{name: "configured", system: &testSystem{systemType: SystemTypeAchievements, config: func() any { return &AchievementsConfig{} }}},
+		{name: "nil system", wantErr: ErrSystemNotConfigured},
+		{name: "nil config", system: &testSystem{systemType: SystemTypeAchievements, config: func() any { return nil }}, wantErr: ErrSystemNotConfigured},
+		{name: "typed nil config", system: &testSystem{systemType: SystemTypeAchievements, config: func() any { return (*AchievementsConfig)(nil) }}, wantErr: ErrSystemNotConfigured},
+		{name: "wrong type", system: &testSystem{systemType: SystemTypeAchievements, config: func() any { return &EconomyConfig{} }}, wantErr: ErrSystemNotConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := GetSystemConfig[*AchievementsConfig](tt.system)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if (config != nil) != (tt.wantErr == nil) {
+				t.Fatalf("config = %v with error %v", config, err)
+			}
+		})
+	}
+}
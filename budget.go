@@ -0,0 +1,142 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// Common phase names recorded by gameplay systems in a request budget.
+const (
+	BudgetPhaseStorageRead  = "storage_read"
+	BudgetPhaseStorageWrite = "storage_write"
+	BudgetPhaseSatoriFetch  = "satori_fetch"
+	BudgetPhaseRewardRoll   = "reward_roll"
+	BudgetPhaseRewardGrant  = "reward_grant"
+)
+
+// RequestBudget tracks the time spent in named phases of a single request, so slow composite operations can report
+// which sub-steps dominate. It is safe for concurrent use, and all methods can be called on a nil budget.
+type RequestBudget struct {
+	mu        sync.Mutex
+	startTime time.Time
+	phases    map[string]*RequestBudgetPhase
+	order     []string
+}
+
+// RequestBudgetPhase is the accumulated time spent in one named phase of a request.
+type RequestBudgetPhase struct {
+	Name       string `json:"name,omitempty"`
+	Count      int    `json:"count,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+}
+
+// RequestBudgetBreakdown is the time spent in each phase of a request, which can be returned in S2S responses when
+// debugging.
+type RequestBudgetBreakdown struct {
+	TotalMs int64                 `json:"total_ms,omitempty"`
+	Phases  []*RequestBudgetPhase `json:"phases,omitempty"`
+}
+
+type requestBudgetContextKey struct{}
+
+// WithRequestBudget returns a context which carries a new request budget, started now.
+func WithRequestBudget(ctx context.Context) (context.Context, *RequestBudget) {
+	budget := &RequestBudget{
+		startTime: time.Now(),
+		phases:    make(map[string]*RequestBudgetPhase),
+	}
+	return context.WithValue(ctx, requestBudgetContextKey{}, budget), budget
+}
+
+// RequestBudgetFromContext returns the request budget set on the context with WithRequestBudget, or nil.
+func RequestBudgetFromContext(ctx context.Context) *RequestBudget {
+	budget, _ := ctx.Value(requestBudgetContextKey{}).(*RequestBudget)
+	return budget
+}
+
+// BudgetPhase starts timing the named phase in the context's request budget, if any. The returned function must be
+// called when the phase ends.
+//
+//	defer hiro.BudgetPhase(ctx, hiro.BudgetPhaseStorageRead)()
+func BudgetPhase(ctx context.Context, name string) func() {
+	return RequestBudgetFromContext(ctx).Phase(name)
+}
+
+// Phase starts timing the named phase. The returned function must be called when the phase ends. Phases with the
+// same name are accumulated.
+func (b *RequestBudget) Phase(name string) func() {
+	if b == nil {
+		return func() {}
+	}
+	startTime := time.Now()
+	return func() {
+		elapsed := time.Since(startTime)
+		b.mu.Lock()
+		phase, found := b.phases[name]
+		if !found {
+			phase = &RequestBudgetPhase{Name: name}
+			b.phases[name] = phase
+			b.order = append(b.order, name)
+		}
+		phase.Count++
+		phase.DurationMs += elapsed.Milliseconds()
+		b.mu.Unlock()
+	}
+}
+
+// Breakdown returns the total time elapsed since the budget started, and the time spent in each phase in the order
+// the phases were first recorded.
+func (b *RequestBudget) Breakdown() *RequestBudgetBreakdown {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breakdown := &RequestBudgetBreakdown{
+		TotalMs: time.Since(b.startTime).Milliseconds(),
+		Phases:  make([]*RequestBudgetPhase, 0, len(b.order)),
+	}
+	for _, name := range b.order {
+		phase := *b.phases[name]
+		breakdown.Phases = append(breakdown.Phases, &phase)
+	}
+	return breakdown
+}
+
+// LogIfSlow logs a structured breakdown of the request when the total time exceeds the threshold. A zero threshold
+// disables logging. It returns true if the request was slow.
+func (b *RequestBudget) LogIfSlow(logger runtime.Logger, operation string, threshold time.Duration) bool {
+	if b == nil || threshold <= 0 {
+		return false
+	}
+	breakdown := b.Breakdown()
+	if time.Duration(breakdown.TotalMs)*time.Millisecond <= threshold {
+		return false
+	}
+
+	fields := make(map[string]interface{}, len(breakdown.Phases)+2)
+	fields["operation"] = operation
+	fields["total_ms"] = breakdown.TotalMs
+	for _, phase := range breakdown.Phases {
+		fields["phase_"+phase.Name+"_ms"] = phase.DurationMs
+	}
+	logger.WithFields(fields).Warn("slow operation")
+	return true
+}
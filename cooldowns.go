@@ -0,0 +1,50 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var (
+	ErrCooldownNotFound = runtime.NewError("cooldown not found", 3)          // INVALID_ARGUMENT
+	ErrCooldownActive   = runtime.NewError("cooldown active", 9)             // FAILED_PRECONDITION
+	ErrCooldownNoToken  = runtime.NewError("cooldown skip token missing", 9) // FAILED_PRECONDITION
+)
+
+// Cooldown is the state of a cooldown for a user.
+type Cooldown struct {
+	Key string `json:"key,omitempty"`
+	// The time when the cooldown ends, zero if the cooldown is not active.
+	EndTimeSec int64 `json:"end_time_sec,omitempty"`
+	// The time when the cooldown was last started.
+	StartTimeSec int64 `json:"start_time_sec,omitempty"`
+}
+
+// Cooldowns tracks per-user cooldowns configured in the economy, and allows them to be reduced or cleared by consuming
+// skip tokens. This generalizes the instant finish of unlockables to any cooldown.
+type Cooldowns interface {
+	// Get returns the current state of a cooldown for the user.
+	Get(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, key string) (cooldown *Cooldown, err error)
+
+	// Start begins the cooldown for the user, and returns ErrCooldownActive if it has not yet ended.
+	Start(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, key string) (cooldown *Cooldown, err error)
+
+	// Skip consumes the first configured skip token the user owns to reduce or clear the cooldown. The consumed result
+	// is false if the cooldown was not active, and ErrCooldownNoToken is returned if the user owns none of the tokens.
+	Skip(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, key string) (cooldown *Cooldown, consumed bool, err error)
+}
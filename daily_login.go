@@ -0,0 +1,73 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"time"
+)
+
+// DailyLoginGrantEventName is the name of the event published when the daily login grant is given to a user.
+const DailyLoginGrantEventName = "dailyLoginGrant"
+
+// BaseSystemConfigDailyLoginGrant gives a reward on the user's first login of each day, separately from any streak
+// rewards. It's applied after authentication, at most once per day per user.
+type BaseSystemConfigDailyLoginGrant struct {
+	Reward *EconomyConfigReward `json:"reward,omitempty"`
+	// If true the day boundary is computed in the timezone pinned with the StreaksSystem instead of UTC.
+	UserTimezone bool `json:"user_timezone,omitempty"`
+}
+
+// DailyLoginGrantState is the stored state of a user's daily login grant.
+type DailyLoginGrantState struct {
+	// The day, formatted as "2006-01-02", on which the grant was last given.
+	LastGrantDay string `json:"last_grant_day,omitempty"`
+	// When the grant was last given.
+	LastGrantTimeSec int64 `json:"last_grant_time_sec,omitempty"`
+}
+
+// DailyLoginGrantDay returns the day, formatted as "2006-01-02", of the given time in the location. A nil location
+// is treated as UTC.
+func DailyLoginGrantDay(nowSec int64, loc *time.Location) string {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return time.Unix(nowSec, 0).In(loc).Format(time.DateOnly)
+}
+
+// Given returns true if the grant has already been given on the day of the given time.
+func (s *DailyLoginGrantState) Given(nowSec int64, loc *time.Location) bool {
+	return s != nil && s.LastGrantDay == DailyLoginGrantDay(nowSec, loc)
+}
+
+// Record marks the grant as given at the given time. It returns false, and makes no changes, if the grant was already
+// given on the same day, so a second login on the same day does not grant again.
+func (s *DailyLoginGrantState) Record(nowSec int64, loc *time.Location) bool {
+	if s.Given(nowSec, loc) {
+		return false
+	}
+	s.LastGrantDay = DailyLoginGrantDay(nowSec, loc)
+	s.LastGrantTimeSec = nowSec
+	return true
+}
+
+// DailyLoginGrantNextResetTimeSec returns when the day after the given time starts in the location, which is when the
+// grant can next be given. A nil location is treated as UTC.
+func DailyLoginGrantNextResetTimeSec(nowSec int64, loc *time.Location) int64 {
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := time.Unix(nowSec, 0).In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc).Unix()
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyLoginGrantState(t *testing.T) {
+	loc := time.FixedZone("UTC+10", 10*60*60)
+	// 2024-03-01 20:00 UTC, which is 2024-03-02 06:00 in UTC+10.
+	nowSec := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC).Unix()
+
+	state := &DailyLoginGrantState{}
+	if !state.Record(nowSec, loc) {
+		t.Fatal("first login of the day was not recorded")
+	}
+	if state.LastGrantDay != "2024-03-02" {
+		t.Errorf("last grant day = %s, want 2024-03-02", state.LastGrantDay)
+	}
+	if state.Record(nowSec+60*60, loc) {
+		t.Error("second login of the day was recorded")
+	}
+
+	next := DailyLoginGrantNextResetTimeSec(nowSec, loc)
+	if want := time.Date(2024, 3, 3, 0, 0, 0, 0, loc).Unix(); next != want {
+		t.Errorf("next reset = %d, want %d", next, want)
+	}
+	if !state.Record(next, loc) {
+		t.Error("login after the reset was not recorded")
+	}
+
+	if next, want := DailyLoginGrantNextResetTimeSec(nowSec, nil), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC).Unix(); next != want {
+		t.Errorf("next reset in UTC = %d, want %d", next, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var (
+	ErrSystemDependencyCycle   = runtime.NewError("system dependency cycle", 13)          // INTERNAL
+	ErrSystemDependencyMissing = runtime.NewError("system dependency missing", 13)        // INTERNAL
+	ErrSystemDuplicate         = runtime.NewError("system configured more than once", 13) // INTERNAL
+)
+
+// SystemConfigDependencies is an optional interface implemented by system configs which declare dependencies, see
+// WithDependencies.
+type SystemConfigDependencies interface {
+	// GetDependencies returns the gameplay systems which must be initialized before this one.
+	GetDependencies() []SystemType
+}
+
+// GetDependencies returns the gameplay systems which must be initialized before the system, or nil if the system config
+// does not declare any dependencies.
+func GetDependencies(config SystemConfig) []SystemType {
+	if sc, ok := systemConfigAs[SystemConfigDependencies](config); ok {
+		return sc.GetDependencies()
+	}
+	return nil
+}
+
+type dependencySystemConfig struct {
+	SystemConfig
+	dependsOn []SystemType
+}
+
+func (sc *dependencySystemConfig) GetDependencies() []SystemType {
+	return slices.Concat(GetDependencies(sc.SystemConfig), sc.dependsOn)
+}
+func (sc *dependencySystemConfig) unwrap() SystemConfig {
+	return sc.SystemConfig
+}
+
+// WithDependencies declares the gameplay systems which must be initialized before this one, such as the stats system
+// for achievements which link to stats. Systems are initialized in dependency order, and Init fails if the declared
+// dependencies form a cycle or name a system which is not configured.
+func WithDependencies(config SystemConfig, dependsOn ...SystemType) SystemConfig {
+	return &dependencySystemConfig{
+		SystemConfig: config,
+		dependsOn:    dependsOn,
+	}
+}
+
+// SystemDependencyError identifies the systems involved in a dependency failure.
+type SystemDependencyError struct {
+	// The systems which form the cycle, starting and ending with the same system, the system with the missing
+	// dependency followed by the dependency, or the system which is configured twice.
+	Systems []SystemType
+	Err     error
+}
+
+func (e *SystemDependencyError) Error() string {
+	names := make([]string, 0, len(e.Systems))
+	for _, system := range e.Systems {
+		names = append(names, system.String())
+	}
+	return e.Err.Error() + ": " + strings.Join(names, " -> ")
+}
+
+func (e *SystemDependencyError) Unwrap() error {
+	return e.Err
+}
+
+// SystemInitOrder returns the configs in the order the systems must be initialized so each is initialized after its
+// dependencies. Systems without an ordering constraint keep their given order. It returns a SystemDependencyError which
+// wraps ErrSystemDependencyCycle or ErrSystemDependencyMissing if the dependencies can't be satisfied, or
+// ErrSystemDuplicate if a system is configured more than once.
+func SystemInitOrder(configs []SystemConfig) ([]SystemConfig, error) {
+	byType := make(map[SystemType]SystemConfig, len(configs))
+	for _, config := range configs {
+		if _, found := byType[config.GetType()]; found {
+			return nil, &SystemDependencyError{Systems: []SystemType{config.GetType()}, Err: ErrSystemDuplicate}
+		}
+		byType[config.GetType()] = config
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[SystemType]int, len(configs))
+	ordered := make([]SystemConfig, 0, len(configs))
+	var path []SystemType
+	var visit func(config SystemConfig) error
+	visit = func(config SystemConfig) error {
+		systemType := config.GetType()
+		switch state[systemType] {
+		case visiting:
+			start := slices.Index(path, systemType)
+			return &SystemDependencyError{Systems: append(slices.Clone(path[start:]), systemType), Err: ErrSystemDependencyCycle}
+		case visited:
+			return nil
+		}
+		state[systemType] = visiting
+		path = append(path, systemType)
+		for _, dependency := range GetDependencies(config) {
+			dependencyConfig, found := byType[dependency]
+			if !found {
+				return &SystemDependencyError{Systems: []SystemType{systemType, dependency}, Err: ErrSystemDependencyMissing}
+			}
+			if err := visit(dependencyConfig); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[systemType] = visited
+		ordered = append(ordered, config)
+		return nil
+	}
+	for _, config := range configs {
+		if err := visit(config); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
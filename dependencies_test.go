@@ -0,0 +1,110 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSystemInitOrder(t *testing.T) {
+	configs := []SystemConfig{
+		WithDependencies(WithAchievementsSystem("achievements.json", true), SystemTypeStats),
+		WithDependencies(WithStateCache(WithDependencies(WithEconomySystem("economy.json", true), SystemTypeInventory), time.Minute), SystemTypeBase),
+		WithStatsSystem("stats.json", true),
+		WithInventorySystem("inventory.json", true),
+		WithBaseSystem("base.json", true),
+	}
+
+	ordered, err := SystemInitOrder(configs)
+	if err != nil {
+		t.Fatalf("init order failed: %v", err)
+	}
+	types := make([]SystemType, 0, len(ordered))
+	for _, config := range ordered {
+		types = append(types, config.GetType())
+	}
+	want := []SystemType{SystemTypeStats, SystemTypeAchievements, SystemTypeInventory, SystemTypeBase, SystemTypeEconomy}
+	if !slices.Equal(types, want) {
+		t.Errorf("init order = %v, want %v", types, want)
+	}
+	if dependencies := GetDependencies(configs[1]); !slices.Equal(dependencies, []SystemType{SystemTypeInventory, SystemTypeBase}) {
+		t.Errorf("dependencies = %v, want [inventory base]", dependencies)
+	}
+}
+
+func TestSystemInitOrderErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []SystemConfig
+		err     error
+		systems []SystemType
+	}{
+		{
+			name: "cycle",
+			configs: []SystemConfig{
+				WithDependencies(WithAchievementsSystem("achievements.json", true), SystemTypeStats),
+				WithDependencies(WithStatsSystem("stats.json", true), SystemTypeEconomy),
+				WithDependencies(WithEconomySystem("economy.json", true), SystemTypeAchievements),
+			},
+			err:     ErrSystemDependencyCycle,
+			systems: []SystemType{SystemTypeAchievements, SystemTypeStats, SystemTypeEconomy, SystemTypeAchievements},
+		},
+		{
+			name: "missing",
+			configs: []SystemConfig{
+				WithDependencies(WithAchievementsSystem("achievements.json", true), SystemTypeStats),
+			},
+			err:     ErrSystemDependencyMissing,
+			systems: []SystemType{SystemTypeAchievements, SystemTypeStats},
+		},
+		{
+			name: "duplicate",
+			configs: []SystemConfig{
+				WithEconomySystem("economy.json", true),
+				WithStateCache(WithEconomySystem("economy_override.json", true), time.Minute),
+			},
+			err:     ErrSystemDuplicate,
+			systems: []SystemType{SystemTypeEconomy},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SystemInitOrder(tt.configs)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("init order returned %v, want %v", err, tt.err)
+			}
+			var dependencyErr *SystemDependencyError
+			if !errors.As(err, &dependencyErr) || !slices.Equal(dependencyErr.Systems, tt.systems) {
+				t.Errorf("init order returned %v, want systems %v", err, tt.systems)
+			}
+		})
+	}
+}
+
+func TestInitDependencyCycle(t *testing.T) {
+	// The cycle is detected before the plugin is loaded, so no Nakama module is needed.
+	_, err := Init(context.Background(), &testLogger{}, nil, nil, "hiro.bin", "",
+		WithDependencies(WithAchievementsSystem("achievements.json", true), SystemTypeStats),
+		WithDependencies(WithStatsSystem("stats.json", true), SystemTypeAchievements),
+	)
+	if !errors.Is(err, ErrSystemDependencyCycle) {
+		t.Errorf("init returned %v, want %v", err, ErrSystemDependencyCycle)
+	}
+}
@@ -16,7 +16,17 @@ package hiro
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/heroiclabs/nakama-common/api"
 	"github.com/heroiclabs/nakama-common/runtime"
@@ -36,20 +46,291 @@ var (
 	ErrEconomyNoDonation        = runtime.NewError("donation not found", 3)                    // INVALID_ARGUMENT
 	ErrEconomyMaxDonation       = runtime.NewError("donation maximum contribution reached", 3) // INVALID_ARGUMENT
 	ErrEconomyClaimedDonation   = runtime.NewError("donation already claimed", 3)              // INVALID_ARGUMENT
+	ErrEconomyGainCapReached    = runtime.NewError("currency gain cap reached", 9)             // FAILED_PRECONDITION
+	ErrEconomyIntentNotFound    = runtime.NewError("purchase intent not found", 3)             // INVALID_ARGUMENT
+	ErrEconomyIntentExpired     = runtime.NewError("purchase intent expired", 9)               // FAILED_PRECONDITION
+	ErrEconomyIntentChanged     = runtime.NewError("purchase intent changed", 9)               // FAILED_PRECONDITION
+	ErrEconomyLegacyPurchase    = runtime.NewError("purchase requires an intent", 9)           // FAILED_PRECONDITION
+	ErrReservationNotFound      = runtime.NewError("reservation not found", 3)                 // INVALID_ARGUMENT
+	ErrReservationExpired       = runtime.NewError("reservation expired", 9)                   // FAILED_PRECONDITION
+	ErrEconomyConfiscateReason  = runtime.NewError("confiscation reason required", 3)          // INVALID_ARGUMENT
+	ErrEconomyOfferChainStep    = runtime.NewError("offer chain step unavailable", 9)          // FAILED_PRECONDITION
+	ErrEconomyRewardCodeInvalid = runtime.NewError("reward code invalid", 3)                   // INVALID_ARGUMENT
+	ErrEconomyRewardCodeClaimed = runtime.NewError("reward code already redeemed", 9)          // FAILED_PRECONDITION
+	ErrEconomyRewardCodeLinked  = runtime.NewError("reward code used by linked account", 9)    // FAILED_PRECONDITION
+	ErrEconomyWebOrderNotFound  = runtime.NewError("web order not found", 3)                   // INVALID_ARGUMENT
+	ErrEconomyWebOrderExpired   = runtime.NewError("web order expired", 9)                     // FAILED_PRECONDITION
+	ErrEconomyWebOrderConfirmed = runtime.NewError("web order already confirmed", 9)           // FAILED_PRECONDITION
+	ErrEconomyWebOrderSignature = runtime.NewError("web order signature invalid", 16)          // UNAUTHENTICATED
+	ErrEconomyChoiceNotFound    = runtime.NewError("reward choice not found", 3)               // INVALID_ARGUMENT
+	ErrEconomyChoiceInvalid     = runtime.NewError("reward choice option invalid", 3)          // INVALID_ARGUMENT
+	ErrEconomyCampaignNotFound  = runtime.NewError("campaign not found", 3)                    // INVALID_ARGUMENT
+	ErrEconomyCampaignEnded     = runtime.NewError("campaign ended", 9)                        // FAILED_PRECONDITION
+	ErrEconomyDailyDealsUnknown = runtime.NewError("daily deals placement not found", 3)       // INVALID_ARGUMENT
+	ErrEconomyDailyDealSoldOut  = runtime.NewError("daily deal purchase limit reached", 9)     // FAILED_PRECONDITION
+	ErrEconomyDailyDealsReroll  = runtime.NewError("daily deals reroll limit reached", 9)      // FAILED_PRECONDITION
+	ErrEconomyCostOperation     = runtime.NewError("cost operation not supported", 3)          // INVALID_ARGUMENT
+	ErrEconomySpendLimitReached = runtime.NewError("monthly spend limit reached", 9)           // FAILED_PRECONDITION
+	ErrClaimWindowClosed        = runtime.NewError("claim window closed", 9)                   // FAILED_PRECONDITION
 
 	ErrInventoryNotInitialized = runtime.NewError("inventory not initialized for batch", 13) // INTERNAL
 	ErrItemsNotConsumable      = runtime.NewError("items not consumable", 3)                 // INVALID_ARGUMENT
 	ErrItemsInsufficient       = runtime.NewError("insufficient items", 9)                   // FAILED_PRECONDITION
+	ErrItemsLocked             = runtime.NewError("items locked", 9)                         // FAILED_PRECONDITION
+	ErrItemBound               = runtime.NewError("item bound", 9)                           // FAILED_PRECONDITION
+	ErrContainerNotFound       = runtime.NewError("inventory container not found", 3)        // INVALID_ARGUMENT
+	ErrContainerFull           = runtime.NewError("inventory container full", 9)             // FAILED_PRECONDITION
+	ErrItemDefinitionInvalid   = runtime.NewError("item definition invalid", 3)              // INVALID_ARGUMENT
+	ErrItemDefinitionStatic    = runtime.NewError("item definition not live", 3)             // INVALID_ARGUMENT
+	ErrItemDefinitionOwned     = runtime.NewError("item definition owned by users", 9)       // FAILED_PRECONDITION
+	ErrItemsGrantBlocked       = runtime.NewError("items grant blocked", 9)                  // FAILED_PRECONDITION
+	ErrAttestationDisabled     = runtime.NewError("inventory attestation disabled", 3)       // INVALID_ARGUMENT
+	ErrAttestationCategory     = runtime.NewError("item category not attestable", 3)         // INVALID_ARGUMENT
+	ErrAttestationInvalid      = runtime.NewError("attestation invalid", 16)                 // UNAUTHENTICATED
+	ErrAttestationExpired      = runtime.NewError("attestation expired", 16)                 // UNAUTHENTICATED
 	ErrCurrencyInsufficient    = runtime.NewError("insufficient currency", 9)                // FAILED_PRECONDITION
 )
 
+// The rounding policies which can be applied to fractional currency amounts.
+const (
+	// EconomyCurrencyRoundingFloor discards any fractional remainder. This is the default.
+	EconomyCurrencyRoundingFloor = "floor"
+	// EconomyCurrencyRoundingRound rounds half away from zero to the nearest unit.
+	EconomyCurrencyRoundingRound = "round"
+	// EconomyCurrencyRoundingCarry floors the amount and banks the remainder to be applied to the next grant.
+	EconomyCurrencyRoundingCarry = "carry"
+)
+
 // EconomyConfig is the data definition for the EconomySystem type.
 type EconomyConfig struct {
-	InitializeUser    *EconomyConfigInitializeUser       `json:"initialize_user,omitempty"`
-	Donations         map[string]*EconomyConfigDonation  `json:"donations,omitempty"`
-	StoreItems        map[string]*EconomyConfigStoreItem `json:"store_items,omitempty"`
-	Placements        map[string]*EconomyConfigPlacement `json:"placements,omitempty"`
-	AllowFakeReceipts bool                               `json:"allow_fake_receipts,omitempty"`
+	InitializeUser    *EconomyConfigInitializeUser        `json:"initialize_user,omitempty"`
+	Currencies        map[string]*EconomyConfigCurrency   `json:"currencies,omitempty"`
+	Donations         map[string]*EconomyConfigDonation   `json:"donations,omitempty"`
+	StoreItems        map[string]*EconomyConfigStoreItem  `json:"store_items,omitempty"`
+	Placements        map[string]*EconomyConfigPlacement  `json:"placements,omitempty"`
+	AllowFakeReceipts bool                                `json:"allow_fake_receipts,omitempty"`
+	PurchaseIntents   *EconomyConfigPurchaseIntents       `json:"purchase_intents,omitempty"`
+	Reservations      *EconomyConfigReservations          `json:"reservations,omitempty"`
+	Metrics           *EconomyConfigMetrics               `json:"metrics,omitempty"`
+	Cooldowns         map[string]*EconomyConfigCooldown   `json:"cooldowns,omitempty"`
+	Confiscation      *EconomyConfigConfiscation          `json:"confiscation,omitempty"`
+	OfferChains       map[string]*EconomyConfigOfferChain `json:"offer_chains,omitempty"`
+	RewardCodes       map[string]*EconomyConfigRewardCode `json:"reward_codes,omitempty"`
+	WebShop           *EconomyConfigWebShop               `json:"web_shop,omitempty"`
+	OfflineProgress   *EconomyConfigOfflineProgress       `json:"offline_progress,omitempty"`
+	Campaigns         map[string]*EconomyConfigCampaign   `json:"campaigns,omitempty"`
+	DailyDeals        map[string]*EconomyConfigDailyDeals `json:"daily_deals,omitempty"`
+	SpendLimits       *EconomyConfigSpendLimits           `json:"spend_limits,omitempty"`
+}
+
+// EconomyConfigCurrency describes how fractional amounts of a currency are stored and rounded.
+//
+// Amounts are stored in the wallet as integers scaled by 10^Precision, so a precision of 2 stores 1.25 as 125. The
+// rounding policy is applied consistently to rewards, reward modifiers, exchanges, and sell-back amounts.
+type EconomyConfigCurrency struct {
+	Precision int                           `json:"precision,omitempty"`
+	Rounding  string                        `json:"rounding,omitempty" hirovalidate:"oneof=floor|round|carry,policy=default"`
+	GainCap   *EconomyConfigCurrencyGainCap `json:"gain_cap,omitempty"`
+	Display   *EconomyConfigCurrencyDisplay `json:"display,omitempty"`
+	Decay     *EconomyConfigCurrencyDecay   `json:"decay,omitempty"`
+	// Optional tax taken from amounts of the currency transferred between players, such as auction settlements.
+	TransferTax *EconomyConfigCurrencyTransferTax `json:"transfer_tax,omitempty"`
+}
+
+// EconomyConfigCurrencyTransferTax removes a fraction of each player-to-player transfer of a currency as a sink. The
+// tax is burned, or credited to the sink user if one is set. The rate in effect when a transfer settles is used and
+// recorded, so changes to the rate do not affect transfers which have already settled.
+type EconomyConfigCurrencyTransferTax struct {
+	// The fraction of the transferred amount removed, between 0 and 1.
+	Rate       float64 `json:"rate,omitempty" hirovalidate:"min=0,max=1,policy=clamp"`
+	SinkUserId string  `json:"sink_user_id,omitempty"`
+}
+
+// EconomyConfigCurrencyDecay slowly reduces unspent balances of a currency above a floor to discourage hoarding.
+type EconomyConfigCurrencyDecay struct {
+	// The fraction of the balance above the floor removed each time decay is applied.
+	Rate float64 `json:"rate,omitempty"`
+	// The balance decay never reduces below.
+	Floor int64 `json:"floor,omitempty"`
+	// The schedule on which decay is applied by the sweep.
+	Cronexpr string `json:"cronexpr,omitempty"`
+}
+
+// EconomyConfigCurrencyDisplay is metadata used by clients to render a currency consistently.
+type EconomyConfigCurrencyDisplay struct {
+	// The name of the currency, may be an i18n code.
+	NameKey       string `json:"name_key,omitempty"`
+	IconId        string `json:"icon_id,omitempty"`
+	DecimalPlaces int    `json:"decimal_places,omitempty"`
+	SortOrder     int    `json:"sort_order,omitempty"`
+	// Hidden currencies are not shown to the user, which can be personalized until the user unlocks the currency.
+	Hidden bool `json:"hidden,omitempty"`
+	// The symbol shown before formatted amounts, such as "$".
+	Symbol string `json:"symbol,omitempty"`
+	// Rules to abbreviate large amounts, such as 1,000,000 as "1M". The rule with the largest threshold not above the
+	// amount is used.
+	Abbreviations []*EconomyConfigCurrencyAbbreviation `json:"abbreviations,omitempty"`
+}
+
+// EconomyConfigCurrencyAbbreviation abbreviates amounts of at least the threshold by dividing them by the threshold
+// and adding the suffix.
+type EconomyConfigCurrencyAbbreviation struct {
+	Threshold     int64  `json:"threshold,omitempty"`
+	Suffix        string `json:"suffix,omitempty"`
+	DecimalPlaces int    `json:"decimal_places,omitempty"`
+}
+
+// FormatCurrency formats an amount of a currency as it's shown to users in server-generated messages, such as
+// notifications, using the currency's precision and display configuration. For example "$1,250" or "1.5M".
+func (c *EconomyConfig) FormatCurrency(currencyID string, amount int64) string {
+	var precision int
+	var display *EconomyConfigCurrencyDisplay
+	if c != nil {
+		if currency, found := c.Currencies[currencyID]; found && currency != nil {
+			precision = currency.Precision
+			display = currency.Display
+		}
+	}
+	if display == nil {
+		display = &EconomyConfigCurrencyDisplay{}
+	}
+
+	value := float64(amount)
+	for i := 0; i < precision; i++ {
+		value /= 10
+	}
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	var abbreviation *EconomyConfigCurrencyAbbreviation
+	for _, a := range display.Abbreviations {
+		if a != nil && a.Threshold > 0 && value >= float64(a.Threshold) && (abbreviation == nil || a.Threshold > abbreviation.Threshold) {
+			abbreviation = a
+		}
+	}
+	// Rounding can carry an amount up to the next abbreviation, such as 999,950 to "1000K", so use the next
+	// abbreviation instead, "1M".
+	for {
+		var next *EconomyConfigCurrencyAbbreviation
+		for _, a := range display.Abbreviations {
+			if a != nil && a.Threshold > 0 && (abbreviation == nil || a.Threshold > abbreviation.Threshold) && (next == nil || a.Threshold < next.Threshold) {
+				next = a
+			}
+		}
+		if next == nil {
+			break
+		}
+		threshold, decimalPlaces := 1.0, display.DecimalPlaces
+		if abbreviation != nil {
+			threshold, decimalPlaces = float64(abbreviation.Threshold), abbreviation.DecimalPlaces
+		}
+		rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value/threshold, 'f', decimalPlaces, 64), 64)
+		if rounded*threshold < float64(next.Threshold) {
+			break
+		}
+		abbreviation = next
+	}
+
+	var formatted string
+	if abbreviation != nil {
+		formatted = strconv.FormatFloat(value/float64(abbreviation.Threshold), 'f', abbreviation.DecimalPlaces, 64)
+		if strings.Contains(formatted, ".") {
+			formatted = strings.TrimRight(strings.TrimRight(formatted, "0"), ".")
+		}
+		formatted += abbreviation.Suffix
+	} else {
+		formatted = strconv.FormatFloat(value, 'f', display.DecimalPlaces, 64)
+		whole, fraction, _ := strings.Cut(formatted, ".")
+		var grouped strings.Builder
+		for i, digit := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				grouped.WriteByte(',')
+			}
+			grouped.WriteRune(digit)
+		}
+		formatted = grouped.String()
+		if fraction != "" {
+			formatted += "." + fraction
+		}
+	}
+
+	return sign + display.Symbol + formatted
+}
+
+// EconomyConfigCurrencyGainCap limits how much of a currency a user can gain within a time window.
+//
+// The cap is enforced on the central grant path so it aggregates rewards, purchases, donations, and direct grants.
+type EconomyConfigCurrencyGainCap struct {
+	// The maximum amount which can be gained in a single window.
+	Max int64 `json:"max,omitempty"`
+	// The CRON expression when the window resets, defaults to daily at midnight UTC.
+	ResetCronexpr string `json:"reset_cronexpr,omitempty"`
+	// If true grants over the cap are clamped to the remaining allowance, otherwise they are rejected.
+	Clamp bool `json:"clamp,omitempty"`
+}
+
+// EconomyCurrencyRounding is recorded in the wallet ledger metadata when a fractional currency amount was rounded.
+type EconomyCurrencyRounding struct {
+	// The amount before any rounding was applied, in whole currency units.
+	Amount float64 `json:"amount"`
+	// The scaled integer amount which was applied to the wallet.
+	Rounded int64 `json:"rounded"`
+	// The rounding policy which was applied.
+	Policy string `json:"policy"`
+	// The fractional remainder banked for the next grant, only set with the carry policy.
+	Carried float64 `json:"carried,omitempty"`
+}
+
+// EconomyConfigPurchaseIntents enables the two-step purchase flow, where a purchase is committed exactly as it was
+// captured by an earlier intent.
+type EconomyConfigPurchaseIntents struct {
+	// How long an intent remains valid before it must be created again.
+	ExpirySec int64 `json:"expiry_sec,omitempty"`
+	// If true the single-step purchase flow remains available alongside intents.
+	AllowLegacyPurchase bool `json:"allow_legacy_purchase,omitempty"`
+}
+
+// EconomyConfigReservations configures how long currencies can be held in reserve before they're released.
+type EconomyConfigReservations struct {
+	// How long a reservation holds currencies before it expires.
+	TtlSec int64 `json:"ttl_sec,omitempty"`
+	// How often expired reservations are swept and their currencies returned to the available balance.
+	SweepIntervalSec int64 `json:"sweep_interval_sec,omitempty"`
+}
+
+// EconomyConfigMetrics enables anonymized aggregate counters of currency sources and sinks.
+type EconomyConfigMetrics struct {
+	// The duration of each time bucket, defaults to one day.
+	BucketDurationSec int64 `json:"bucket_duration_sec,omitempty"`
+	// How many buckets are kept before the oldest are removed.
+	MaxBuckets int `json:"max_buckets,omitempty"`
+}
+
+// EconomyConfigConfiscation controls how currencies are removed from compromised accounts.
+type EconomyConfigConfiscation struct {
+	// If true currency deductions may drive a balance negative instead of stopping at zero.
+	AllowNegative bool `json:"allow_negative,omitempty"`
+	// If true later grants of a currency first pay down a negative balance before the remainder is added.
+	PayDownNegative bool `json:"pay_down_negative,omitempty"`
+}
+
+// EconomyConfigCooldown describes a cooldown and the tokens which can be consumed to skip it.
+type EconomyConfigCooldown struct {
+	DurationSec int64 `json:"duration_sec,omitempty"`
+	// The tokens which can skip the cooldown, tried in order until one the user owns is found.
+	SkipTokens []*EconomyConfigCooldownSkipToken `json:"skip_tokens,omitempty"`
+}
+
+// EconomyConfigCooldownSkipToken is an inventory item or currency consumed to reduce or clear a cooldown.
+type EconomyConfigCooldownSkipToken struct {
+	ItemId   string `json:"item_id,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Amount   int64  `json:"amount,omitempty"`
+	// The number of seconds the cooldown is reduced by, zero clears the cooldown.
+	ReduceSec int64 `json:"reduce_sec,omitempty"`
 }
 
 type EconomyConfigDonation struct {
@@ -86,6 +367,90 @@ type EconomyConfigReward struct {
 	MaxRolls       int64                          `json:"max_rolls,omitempty"`
 	MaxRepeatRolls int64                          `json:"max_repeat_rolls,omitempty"`
 	TotalWeight    int64                          `json:"total_weight,omitempty"`
+	Choice         *EconomyConfigRewardChoice     `json:"choice,omitempty"`
+	Pity           *EconomyConfigRewardPity       `json:"pity,omitempty"`
+}
+
+// Outcomes returns every outcome of a single weighted roll of the reward with its normalized probability. The
+// probabilities sum to 1 and are proportional to the configured weights, with a -1 outcome for the remainder when
+// the total weight is larger than the sum of the weights. If the pity counters force a rare roll, only the rare
+// contents are outcomes, with probabilities proportional to their weights among the rare contents.
+func (r *EconomyConfigReward) Outcomes(counters EconomyPityCounters) []*EconomyRewardOutcome {
+	if r == nil || len(r.Weighted) == 0 {
+		return nil
+	}
+
+	forced := counters.Forced(r.Pity)
+	outcomes := make([]*EconomyRewardOutcome, 0, len(r.Weighted)+1)
+	var sum int64
+	for i, contents := range r.Weighted {
+		if contents == nil || contents.Weight <= 0 {
+			continue
+		}
+		if forced && !slices.Contains(r.Pity.RareIndexes, i) {
+			continue
+		}
+		sum += contents.Weight
+		outcomes = append(outcomes, &EconomyRewardOutcome{Index: i, Contents: contents, Probability: float64(contents.Weight)})
+	}
+	if sum == 0 {
+		return nil
+	}
+
+	total := sum
+	if !forced && r.TotalWeight > sum {
+		total = r.TotalWeight
+		outcomes = append(outcomes, &EconomyRewardOutcome{Index: -1, Probability: float64(r.TotalWeight - sum)})
+	}
+	for _, outcome := range outcomes {
+		outcome.Probability /= float64(total)
+	}
+	return outcomes
+}
+
+// EconomyConfigRewardPity guarantees a rare weighted roll within a number of rolls. The pity counter is shared by all
+// rewards in the same group, such as the loot tables of a season, and persisted per user.
+type EconomyConfigRewardPity struct {
+	// The group whose counter the reward shares. An empty group is not independent: every reward without a group
+	// shares the same counter, so rewards which should count separately must each set their own group.
+	Group string `json:"group,omitempty"`
+	// The maximum number of rolls across the group before a rare roll is guaranteed.
+	Rolls int64 `json:"rolls,omitempty"`
+	// The indexes of the weighted contents which are rare.
+	RareIndexes []int `json:"rare_indexes,omitempty"`
+}
+
+// EconomyPityCounters are a user's pity counters keyed by group, counting rolls made since the last rare roll.
+type EconomyPityCounters map[string]int64
+
+// Forced returns true if the next roll of a reward with the pity must be limited to its rare contents.
+func (c EconomyPityCounters) Forced(pity *EconomyConfigRewardPity) bool {
+	return pity != nil && pity.Rolls > 0 && c[pity.Group] >= pity.Rolls-1
+}
+
+// Record advances the group's pity counter for a roll of the weighted contents index, or resets it if the roll was rare.
+func (c EconomyPityCounters) Record(pity *EconomyConfigRewardPity, index int) {
+	if pity == nil {
+		return
+	}
+	if slices.Contains(pity.RareIndexes, index) {
+		c[pity.Group] = 0
+		return
+	}
+	c[pity.Group]++
+}
+
+// EconomyConfigRewardChoice lets the player choose one of several reward options instead of receiving all of them.
+// Granting the reward creates a pending choice with each option rolled and pinned at grant time.
+type EconomyConfigRewardChoice struct {
+	Options []*EconomyConfigRewardContents `json:"options,omitempty"`
+	// The index of the option granted if the choice expires before the player selects one.
+	DefaultOption int `json:"default_option,omitempty"`
+	// How long the player has to choose, zero never expires.
+	ExpirySec int64 `json:"expiry_sec,omitempty"`
+	// An optional live event the choice is bound to. The choice can only be claimed while the event is active, and
+	// is removed without granting the default option once the event ends.
+	LiveEventId string `json:"live_event_id,omitempty"`
 }
 
 type EconomyConfigRewardContents struct {
@@ -98,6 +463,15 @@ type EconomyConfigRewardContents struct {
 	Weight          int64                                   `json:"weight,omitempty"`
 }
 
+// EconomyRewardOutcome is one possible result of a single weighted roll of a reward, with its normalized probability.
+type EconomyRewardOutcome struct {
+	// The index of the weighted contents, or -1 for the outcome where no weighted contents are rolled because the
+	// total weight is larger than the sum of the weights.
+	Index       int                          `json:"index"`
+	Contents    *EconomyConfigRewardContents `json:"contents,omitempty"`
+	Probability float64                      `json:"probability"`
+}
+
 type EconomyConfigRewardCurrency struct {
 	EconomyConfigRewardRangeInt64
 }
@@ -176,11 +550,185 @@ type EconomyConfigStoreItem struct {
 	AdditionalProperties map[string]string           `json:"additional_properties,omitempty"`
 	Disabled             bool                        `json:"disabled,omitempty"`
 	Unavailable          bool                        `json:"unavailable,omitempty"`
+	// Optional prerequisite checked on purchase, which fails with a *PrerequisiteError if it is not met.
+	Prerequisite *Prerequisite `json:"prerequisite,omitempty"`
+	// Optional window relative to when the user was first seen, such as a starter bundle. Outside of the window the
+	// store item is not listed, and purchases fail with ErrEconomyItemUnavailable.
+	FirstSeenWindow *EconomyConfigStoreItemFirstSeenWindow `json:"first_seen_window,omitempty"`
+}
+
+// EconomyConfigStoreItemFirstSeenWindow makes a store item available for a duration starting at an offset from when
+// the user was first seen.
+type EconomyConfigStoreItemFirstSeenWindow struct {
+	OffsetSec   int64 `json:"offset_sec,omitempty"`
+	DurationSec int64 `json:"duration_sec,omitempty"`
+}
+
+// EconomyStoreItemPropertyWindowRemainingSec is the reserved additional property set on listed store items with a
+// first seen window, which holds the seconds remaining until the window closes for a countdown.
+const EconomyStoreItemPropertyWindowRemainingSec = "hiro_window_remaining_sec"
+
+// Remaining returns whether the window is open, and the seconds remaining until it closes. A first seen time which is
+// not recorded yet, or is ahead of now due to clock skew between servers, is treated as now. A zero duration never
+// closes once open, and reports zero remaining.
+func (w *EconomyConfigStoreItemFirstSeenWindow) Remaining(firstSeenTimeSec, nowSec int64) (open bool, remainingSec int64) {
+	if w == nil {
+		return true, 0
+	}
+	if firstSeenTimeSec <= 0 || firstSeenTimeSec > nowSec {
+		firstSeenTimeSec = nowSec
+	}
+
+	startSec := firstSeenTimeSec + w.OffsetSec
+	if nowSec < startSec {
+		return false, 0
+	}
+	if w.DurationSec <= 0 {
+		return true, 0
+	}
+	endSec := startSec + w.DurationSec
+	if nowSec >= endSec {
+		return false, 0
+	}
+	return true, endSec - nowSec
+}
+
+// EconomyConfigRewardCode is a code which grants a reward when redeemed, such as for a cross-promotion.
+type EconomyConfigRewardCode struct {
+	Reward       *EconomyConfigReward `json:"reward,omitempty"`
+	StartTimeSec int64                `json:"start_time_sec,omitempty"`
+	EndTimeSec   int64                `json:"end_time_sec,omitempty"`
+	// If true the code can be redeemed once per account family, as resolved by the family resolver, rather than once
+	// per account.
+	FamilyScoped bool `json:"family_scoped,omitempty"`
+}
+
+// EconomyConfigWebShop configures purchases of store items made on a web shop outside of the app stores.
+type EconomyConfigWebShop struct {
+	// The key used to verify the HMAC-SHA256 signature of order confirmation callbacks from the web shop backend.
+	SigningKey string `json:"signing_key,omitempty"`
+	// How long an order waits for confirmation before it expires.
+	OrderExpirySec int64 `json:"order_expiry_sec,omitempty"`
+}
+
+// EconomyConfigSpendLimits caps the real-money spend of each user per calendar month in UTC, such as for parental
+// controls or where required by law. Validated app store purchases and confirmed web shop orders are both counted, and
+// purchases which would exceed the cap are rejected with an EconomySpendLimitError.
+type EconomyConfigSpendLimits struct {
+	// The default monthly limit for users without their own limit, in minor units of the currency such as cents. Zero
+	// means users without their own limit are not capped.
+	MonthlyLimit int64 `json:"monthly_limit,omitempty"`
+	// The ISO 4217 code of the currency the limit and prices are in, such as "USD".
+	Currency string `json:"currency,omitempty"`
+	// The price of each SKU in minor units of the currency, used to count spend.
+	SkuPrices map[string]int64 `json:"sku_prices,omitempty"`
+}
+
+// The reserved additional properties set on listed store items with a SKU when spend limits are configured.
+const (
+	// EconomyStoreItemPropertySpendLimitRemaining is the user's remaining real-money allowance for the month.
+	EconomyStoreItemPropertySpendLimitRemaining = "hiro_spend_limit_remaining"
+	// EconomyStoreItemPropertySpendLimitResetTimeSec is when the user's allowance resets at the start of next month.
+	EconomyStoreItemPropertySpendLimitResetTimeSec = "hiro_spend_limit_reset_time_sec"
+)
+
+// EconomySpendLimit is a user's real-money spend in the current month against their limit.
+type EconomySpendLimit struct {
+	// The month, formatted as "2006-01", the spend is counted in.
+	Month string `json:"month,omitempty"`
+	// The user's monthly limit, zero if not capped.
+	MonthlyLimit int64 `json:"monthly_limit,omitempty"`
+	// True if the limit was set for the user rather than taken from the config.
+	Override     bool  `json:"override,omitempty"`
+	Spent        int64 `json:"spent,omitempty"`
+	Remaining    int64 `json:"remaining,omitempty"`
+	ResetTimeSec int64 `json:"reset_time_sec,omitempty"`
+}
+
+// EconomySpendLimitMonth returns the calendar month, formatted as "2006-01", of the given time in UTC and the time the
+// next month starts.
+func EconomySpendLimitMonth(nowSec int64) (month string, resetTimeSec int64) {
+	now := time.Unix(nowSec, 0).UTC()
+	return now.Format("2006-01"), time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+// Check returns an EconomySpendLimitError if spending the amount would exceed the limit.
+func (l *EconomySpendLimit) Check(amount int64) error {
+	if l == nil || l.MonthlyLimit <= 0 || amount <= l.Remaining {
+		return nil
+	}
+	return &EconomySpendLimitError{Remaining: l.Remaining, ResetTimeSec: l.ResetTimeSec}
+}
+
+// EconomySpendLimitError is returned for purchases beyond the user's monthly spend limit.
+type EconomySpendLimitError struct {
+	// The remaining allowance for the month in minor units of the currency.
+	Remaining int64
+	// When the allowance resets at the start of next month.
+	ResetTimeSec int64
+}
+
+func (e *EconomySpendLimitError) Error() string {
+	return ErrEconomySpendLimitReached.Error() + ": " + strconv.FormatInt(e.Remaining, 10) + " remaining until " + time.Unix(e.ResetTimeSec, 0).UTC().Format(time.DateOnly)
+}
+
+func (e *EconomySpendLimitError) Unwrap() error {
+	return ErrEconomySpendLimitReached
+}
+
+// EconomyConfigOfferChain is an ordered ladder of store items, where purchasing a step reveals the next one.
+//
+// Store items which are steps in a chain are only listed and purchasable while they're the user's current step.
+type EconomyConfigOfferChain struct {
+	Steps []*EconomyConfigOfferChainStep `json:"steps,omitempty"`
+	// An optional schedule on which each user's position in the chain is reset to the first step.
+	ResetCronexpr string `json:"reset_cronexpr,omitempty"`
+}
+
+type EconomyConfigOfferChainStep struct {
+	StoreItemId string `json:"store_item_id,omitempty"`
+	// How long the step is available after the previous step is purchased, zero is unlimited. The first step is
+	// available from when the chain starts or resets.
+	AvailabilitySec int64 `json:"availability_sec,omitempty"`
 }
 
 type EconomyConfigStoreItemCost struct {
 	Currencies map[string]int64 `json:"currencies,omitempty"`
 	Sku        string           `json:"sku,omitempty"`
+	// Optional per-unit prices for purchases of a quantity at or above a threshold, such as a cheaper price each when
+	// buying 10. They're returned to clients with the store item.
+	PriceBreaks []*EconomyConfigStoreItemPriceBreak `json:"price_breaks,omitempty"`
+}
+
+// EconomyConfigStoreItemPriceBreak is the per-unit currency price of a store item when a purchase is at least the
+// minimum quantity.
+type EconomyConfigStoreItemPriceBreak struct {
+	MinQuantity int64            `json:"min_quantity,omitempty"`
+	Currencies  map[string]int64 `json:"currencies,omitempty"`
+}
+
+// Total returns the currency cost of purchasing a quantity of the store item, using the price break with the highest
+// minimum quantity the purchase reaches, or the base currencies if it reaches none.
+func (c *EconomyConfigStoreItemCost) Total(quantity int64) map[string]int64 {
+	if c == nil || quantity <= 0 {
+		return nil
+	}
+
+	unit := c.Currencies
+	var reached int64
+	for _, priceBreak := range c.PriceBreaks {
+		if priceBreak == nil || priceBreak.MinQuantity > quantity || priceBreak.MinQuantity <= reached {
+			continue
+		}
+		unit = priceBreak.Currencies
+		reached = priceBreak.MinQuantity
+	}
+
+	total := make(map[string]int64, len(unit))
+	for currencyID, amount := range unit {
+		total[currencyID] = amount * quantity
+	}
+	return total
 }
 
 // EconomyPlacementInfo contains information about a placement instance.
@@ -191,6 +739,513 @@ type EconomyPlacementInfo struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// EconomyPurchaseIntentInfo is a single-use capture of a store item purchase as it was resolved for a user.
+type EconomyPurchaseIntentInfo struct {
+	Id     string           `json:"id,omitempty"`
+	ItemId string           `json:"item_id,omitempty"`
+	Store  EconomyStoreType `json:"store,omitempty"`
+	Sku    string           `json:"sku,omitempty"`
+	// The cost of the store item after any personalization or discounts were applied.
+	Cost *EconomyConfigStoreItemCost `json:"cost,omitempty"`
+	// The reward of the store item after any personalization or bonuses were applied.
+	Reward *EconomyConfigReward `json:"reward,omitempty"`
+	// A hash of the store item configuration used to detect changes before the intent is committed.
+	ConfigHash    string `json:"config_hash,omitempty"`
+	CreateTimeSec int64  `json:"create_time_sec,omitempty"`
+	ExpiryTimeSec int64  `json:"expiry_time_sec,omitempty"`
+}
+
+// EconomyReservation holds currencies from a user's wallet until it's committed, released, or expires.
+type EconomyReservation struct {
+	Id            string           `json:"id,omitempty"`
+	UserId        string           `json:"user_id,omitempty"`
+	Currencies    map[string]int64 `json:"currencies,omitempty"`
+	CreateTimeSec int64            `json:"create_time_sec,omitempty"`
+	ExpiryTimeSec int64            `json:"expiry_time_sec,omitempty"`
+}
+
+// EconomyConfiscation is a set of deductions made from a user's account, such as by fraud operations.
+type EconomyConfiscation struct {
+	// The currency amounts to deduct, as positive numbers.
+	Currencies map[string]int64 `json:"currencies,omitempty"`
+	// The instance IDs of inventory items to remove.
+	ItemInstanceIds []string `json:"item_instance_ids,omitempty"`
+	// If true the user is sent a notification of the confiscation.
+	Notify bool `json:"notify,omitempty"`
+}
+
+// EconomyOfferChain is a user's position in an offer chain.
+type EconomyOfferChain struct {
+	Id string `json:"id,omitempty"`
+	// The index of the current step, equal to the number of steps when the chain is complete.
+	Step        int    `json:"step,omitempty"`
+	StoreItemId string `json:"store_item_id,omitempty"`
+	// The deadline to purchase the current step, zero if it does not expire.
+	ExpiryTimeSec int64 `json:"expiry_time_sec,omitempty"`
+	// The next time the chain resets, zero if it does not reset.
+	ResetTimeSec int64 `json:"reset_time_sec,omitempty"`
+}
+
+// The status of a web shop order.
+const (
+	EconomyWebOrderStatusPending   = "pending"
+	EconomyWebOrderStatusConfirmed = "confirmed"
+	EconomyWebOrderStatusExpired   = "expired"
+)
+
+// EconomyLedgerMetadataExternal is the wallet ledger metadata key set on grants made for purchases outside of the app
+// stores, such as web shop orders. Its value is the order ID.
+const EconomyLedgerMetadataExternal = "external"
+
+// EconomyLedgerMetadataTransferTax is the wallet ledger metadata key set on both sides of a taxed transfer. Its value
+// is the list of EconomyTransferTax applied.
+const EconomyLedgerMetadataTransferTax = "transfer_tax"
+
+// EconomyTransferTax is the tax taken from a currency in a player-to-player transfer.
+type EconomyTransferTax struct {
+	CurrencyId string  `json:"currency_id,omitempty"`
+	Amount     int64   `json:"amount,omitempty"`
+	Rate       float64 `json:"rate,omitempty"`
+	SinkUserId string  `json:"sink_user_id,omitempty"`
+}
+
+// TransferTax computes the tax on currencies transferred between players. It returns the amounts the receiver is
+// credited, and the taxes taken, sorted by currency. Tax amounts are rounded down so the receiver is never taxed more
+// than the configured rate.
+func (c *EconomyConfig) TransferTax(currencies map[string]int64) (net map[string]int64, taxes []*EconomyTransferTax) {
+	net = make(map[string]int64, len(currencies))
+	for currencyID, amount := range currencies {
+		net[currencyID] = amount
+		if c == nil || amount <= 0 {
+			continue
+		}
+		currency, found := c.Currencies[currencyID]
+		if !found || currency == nil || currency.TransferTax == nil || currency.TransferTax.Rate <= 0 {
+			continue
+		}
+		tax := int64(math.Floor(float64(amount) * math.Min(currency.TransferTax.Rate, 1)))
+		if tax <= 0 {
+			continue
+		}
+		net[currencyID] = amount - tax
+		taxes = append(taxes, &EconomyTransferTax{
+			CurrencyId: currencyID,
+			Amount:     tax,
+			Rate:       currency.TransferTax.Rate,
+			SinkUserId: currency.TransferTax.SinkUserId,
+		})
+	}
+	slices.SortFunc(taxes, func(a, b *EconomyTransferTax) int {
+		return strings.Compare(a.CurrencyId, b.CurrencyId)
+	})
+	return net, taxes
+}
+
+// EconomyConfigOfflineProgress accrues currencies while the user is offline, such as for idle games, which are granted
+// when they next log in.
+type EconomyConfigOfflineProgress struct {
+	// The longest offline duration which accrues, zero is unlimited.
+	MaxDurationSec int64                                        `json:"max_duration_sec,omitempty"`
+	Rates          map[string]*EconomyConfigOfflineProgressRate `json:"rates,omitempty"`
+}
+
+// EconomyConfigOfflineProgressRate is an amount of currencies accrued each second offline.
+type EconomyConfigOfflineProgressRate struct {
+	Currencies map[string]float64 `json:"currencies,omitempty"`
+	// If set the rate only applies once the user has unlocked the progression.
+	ProgressionId string `json:"progression_id,omitempty"`
+}
+
+// EconomyOfflineProgress is the result of accruing offline progress for a user.
+type EconomyOfflineProgress struct {
+	LastSeenTimeSec int64 `json:"last_seen_time_sec,omitempty"`
+	// The time the user was offline, and the part of it which accrued after the max duration was applied.
+	OfflineSec int64 `json:"offline_sec,omitempty"`
+	AccruedSec int64 `json:"accrued_sec,omitempty"`
+	// True if the max duration limited the accrual.
+	Capped     bool             `json:"capped,omitempty"`
+	Currencies map[string]int64 `json:"currencies,omitempty"`
+}
+
+// Accrue computes the currencies earned between the last seen time and now. Rates gated by a progression only apply
+// if unlocked reports the progression as unlocked, a nil function treats every gated rate as locked. Fractional
+// amounts are rounded down.
+func (c *EconomyConfigOfflineProgress) Accrue(lastSeenTimeSec, nowSec int64, unlocked func(progressionID string) bool) *EconomyOfflineProgress {
+	progress := &EconomyOfflineProgress{
+		LastSeenTimeSec: lastSeenTimeSec,
+		Currencies:      make(map[string]int64),
+	}
+	if c == nil || lastSeenTimeSec <= 0 || nowSec <= lastSeenTimeSec {
+		return progress
+	}
+
+	progress.OfflineSec = nowSec - lastSeenTimeSec
+	progress.AccruedSec = progress.OfflineSec
+	if c.MaxDurationSec > 0 && progress.AccruedSec > c.MaxDurationSec {
+		progress.AccruedSec = c.MaxDurationSec
+		progress.Capped = true
+	}
+
+	perSec := make(map[string]float64)
+	for _, rate := range c.Rates {
+		if rate == nil {
+			continue
+		}
+		if rate.ProgressionId != "" && (unlocked == nil || !unlocked(rate.ProgressionId)) {
+			continue
+		}
+		for currencyID, amount := range rate.Currencies {
+			perSec[currencyID] += amount
+		}
+	}
+	for currencyID, amount := range perSec {
+		if accrued := int64(math.Floor(amount * float64(progress.AccruedSec))); accrued > 0 {
+			progress.Currencies[currencyID] = accrued
+		}
+	}
+	return progress
+}
+
+// EconomyConfigCampaign is a drip campaign which grants a reward once per day to each enrolled user for the duration
+// of the campaign.
+type EconomyConfigCampaign struct {
+	StartTimeSec int64 `json:"start_time_sec,omitempty"`
+	DurationDays int   `json:"duration_days,omitempty"`
+	// The reward granted each campaign day.
+	Reward *EconomyConfigReward `json:"reward,omitempty"`
+	// Optional personalization flag which enrolls the user when its value is "true", in addition to users enrolled
+	// directly.
+	Flag string `json:"flag,omitempty"`
+}
+
+// EconomyCampaignProgress is a user's progress through a drip campaign.
+type EconomyCampaignProgress struct {
+	CampaignId    string `json:"campaign_id,omitempty"`
+	EnrollTimeSec int64  `json:"enroll_time_sec,omitempty"`
+	// The last campaign day granted, starting at 1, or zero if nothing has been granted yet.
+	LastGrantDay int `json:"last_grant_day,omitempty"`
+}
+
+// Due returns the current campaign day, starting at 1, and whether its reward is still to be granted to the user.
+// Nothing is due before the campaign starts, after it ends, or if the day has already been granted.
+func (c *EconomyConfigCampaign) Due(progress *EconomyCampaignProgress, nowSec int64) (day int, due bool) {
+	if c == nil || nowSec < c.StartTimeSec {
+		return 0, false
+	}
+	day = int((nowSec-c.StartTimeSec)/86400) + 1
+	if c.DurationDays > 0 && day > c.DurationDays {
+		return day, false
+	}
+	if progress != nil && progress.LastGrantDay >= day {
+		return day, false
+	}
+	return day, true
+}
+
+// EconomyConfigDailyDeals is a placement of deals drawn each day for each user from a weighted pool of store items.
+// The draw is seeded by the user, the date, and the placement, and persisted when it's first made, so the deals are
+// stable for the whole day across devices and unaffected by later changes to the pool or personalization.
+type EconomyConfigDailyDeals struct {
+	// The number of deals drawn each day.
+	Count int                                `json:"count,omitempty"`
+	Pool  []*EconomyConfigDailyDealsPoolItem `json:"pool,omitempty"`
+	// If true store items whose reward items the user owns when the deals are drawn are excluded.
+	ExcludeOwned bool                           `json:"exclude_owned,omitempty"`
+	Reroll       *EconomyConfigDailyDealsReroll `json:"reroll,omitempty"`
+}
+
+// EconomyConfigDailyDealsPoolItem is a store item which may be drawn as a daily deal.
+type EconomyConfigDailyDealsPoolItem struct {
+	StoreItemId string `json:"store_item_id,omitempty"`
+	Weight      int64  `json:"weight,omitempty"`
+	// The number of times the deal can be purchased in a day, zero is unlimited.
+	PurchaseLimit int `json:"purchase_limit,omitempty"`
+}
+
+// EconomyConfigDailyDealsReroll allows the user to pay to draw a new set of deals for the day.
+type EconomyConfigDailyDealsReroll struct {
+	Cost *EconomyConfigStoreItemCost `json:"cost,omitempty"`
+	// The maximum number of rerolls each day, zero disables rerolls.
+	MaxPerDay int `json:"max_per_day,omitempty"`
+}
+
+// EconomyDailyDeals is a user's deals for a placement on a day.
+type EconomyDailyDeals struct {
+	PlacementId string `json:"placement_id,omitempty"`
+	// The UTC date the deals were drawn for, formatted as YYYY-MM-DD.
+	Date    string              `json:"date,omitempty"`
+	Deals   []*EconomyDailyDeal `json:"deals,omitempty"`
+	Rerolls int                 `json:"rerolls,omitempty"`
+}
+
+// EconomyDailyDeal is a store item drawn as a daily deal, and the number of times the user has purchased it today.
+type EconomyDailyDeal struct {
+	StoreItemId   string `json:"store_item_id,omitempty"`
+	PurchaseLimit int    `json:"purchase_limit,omitempty"`
+	Purchased     int    `json:"purchased,omitempty"`
+}
+
+// Draw makes the weighted draw of deals for a user on a date, after the given number of rerolls. The same inputs always
+// draw the same deals. Store items are drawn without replacement, and owned reports whether the user owns a store
+// item's reward so it's excluded when configured, a nil function excludes nothing.
+func (c *EconomyConfigDailyDeals) Draw(userID, date, placementID string, rerolls int, owned func(storeItemID string) bool) []*EconomyDailyDeal {
+	if c == nil || c.Count <= 0 {
+		return nil
+	}
+
+	candidates := make([]*EconomyConfigDailyDealsPoolItem, 0, len(c.Pool))
+	var totalWeight int64
+	for _, item := range c.Pool {
+		if item == nil || item.Weight <= 0 || item.StoreItemId == "" {
+			continue
+		}
+		if c.ExcludeOwned && owned != nil && owned(item.StoreItemId) {
+			continue
+		}
+		candidates = append(candidates, item)
+		totalWeight += item.Weight
+	}
+
+	h := fnv.New64a()
+	for _, part := range []string{userID, date, placementID, strconv.Itoa(rerolls)} {
+		_, _ = h.Write([]byte(part))
+		_, _ = h.Write([]byte{0})
+	}
+	seed := h.Sum64()
+	r := rand.New(rand.NewPCG(seed, seed>>1))
+
+	deals := make([]*EconomyDailyDeal, 0, min(c.Count, len(candidates)))
+	for len(deals) < c.Count && totalWeight > 0 {
+		point := r.Int64N(totalWeight)
+		for i, item := range candidates {
+			if point >= item.Weight {
+				point -= item.Weight
+				continue
+			}
+			deals = append(deals, &EconomyDailyDeal{
+				StoreItemId:   item.StoreItemId,
+				PurchaseLimit: item.PurchaseLimit,
+			})
+			totalWeight -= item.Weight
+			candidates = slices.Delete(candidates, i, i+1)
+			break
+		}
+	}
+	return deals
+}
+
+// EconomyWebOrder is a purchase of a store item made on a web shop.
+type EconomyWebOrder struct {
+	Id             string  `json:"id,omitempty"`
+	UserId         string  `json:"user_id,omitempty"`
+	ItemId         string  `json:"item_id,omitempty"`
+	Status         string  `json:"status,omitempty"`
+	CreateTimeSec  int64   `json:"create_time_sec,omitempty"`
+	ExpiryTimeSec  int64   `json:"expiry_time_sec,omitempty"`
+	ConfirmTimeSec int64   `json:"confirm_time_sec,omitempty"`
+	Reward         *Reward `json:"reward,omitempty"`
+}
+
+// EconomyWebOrderSign returns the hex encoded HMAC-SHA256 signature of a web shop callback payload.
+func EconomyWebOrderSign(key string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EconomyWebOrderVerify returns true if the signature is the valid HMAC-SHA256 signature of the payload. The
+// comparison is made in constant time.
+func EconomyWebOrderVerify(key string, payload []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// EconomyRewardChoice is a pending choice between reward options which were rolled when the reward was granted, so
+// they're unaffected by personalization changes made before the choice is claimed.
+type EconomyRewardChoice struct {
+	Id            string    `json:"id,omitempty"`
+	Options       []*Reward `json:"options,omitempty"`
+	DefaultOption int       `json:"default_option,omitempty"`
+	CreateTimeSec int64     `json:"create_time_sec,omitempty"`
+	ExpiryTimeSec int64     `json:"expiry_time_sec,omitempty"`
+	// The live event the choice is bound to, if any.
+	LiveEventId string `json:"live_event_id,omitempty"`
+}
+
+// ClaimWindowOpen returns true if the choice can still be claimed at the given time. A choice bound to a live event
+// can only be claimed while the event is one of the active live events.
+func (c *EconomyRewardChoice) ClaimWindowOpen(liveEvents []*runtime.LiveEvent, nowSec int64) bool {
+	if c.LiveEventId == "" {
+		return true
+	}
+	for _, liveEvent := range liveEvents {
+		if liveEvent == nil {
+			continue
+		}
+		if liveEvent.Id == c.LiveEventId {
+			return liveEvent.ActiveStartTimeSec <= nowSec && (liveEvent.ActiveEndTimeSec == 0 || nowSec < liveEvent.ActiveEndTimeSec)
+		}
+	}
+	return false
+}
+
+// EconomyCurrencyMetadata is the display metadata of a currency returned to clients.
+type EconomyCurrencyMetadata struct {
+	Id            string `json:"id,omitempty"`
+	NameKey       string `json:"name_key,omitempty"`
+	IconId        string `json:"icon_id,omitempty"`
+	DecimalPlaces int    `json:"decimal_places,omitempty"`
+	SortOrder     int    `json:"sort_order,omitempty"`
+	// The last time decay was applied to the user's balance, zero if the currency has never decayed.
+	DecayTimeSec int64 `json:"decay_time_sec,omitempty"`
+	// The next time decay is scheduled, zero if the currency does not decay.
+	NextDecayTimeSec int64 `json:"next_decay_time_sec,omitempty"`
+}
+
+// The kinds of transactions which are counted in economy metrics.
+const (
+	EconomyMetricsSourceGrant        = "grant"
+	EconomyMetricsSourcePurchase     = "purchase"
+	EconomyMetricsSourceSell         = "sell"
+	EconomyMetricsSourceExchange     = "exchange"
+	EconomyMetricsSourceConfiscation = "confiscation"
+	EconomyMetricsSourceDecay        = "decay"
+)
+
+// EconomyMetricsBucket contains the aggregate currency counters for a single time bucket.
+type EconomyMetricsBucket struct {
+	StartTimeSec int64                              `json:"start_time_sec,omitempty"`
+	EndTimeSec   int64                              `json:"end_time_sec,omitempty"`
+	Currencies   map[string]*EconomyMetricsCurrency `json:"currencies,omitempty"`
+}
+
+// EconomyMetricsCurrency contains the amounts of a currency which entered and left the economy, by transaction kind.
+type EconomyMetricsCurrency struct {
+	Sources map[string]int64 `json:"sources,omitempty"`
+	Sinks   map[string]int64 `json:"sinks,omitempty"`
+}
+
+// The reward content types a buff can apply to.
+const (
+	EconomyBuffRewardTypeCurrency = "currency"
+	EconomyBuffRewardTypeItem     = "item"
+	EconomyBuffRewardTypeEnergy   = "energy"
+)
+
+// EconomyBuffMetadataMultiplier is the published event metadata key set on grants which had buffs applied. Its value
+// is the combined multiplier of the applicable buffs.
+const EconomyBuffMetadataMultiplier = "buff_multiplier"
+
+// EconomyBuff is a temporary multiplier on the amounts a user is granted, such as double coins.
+type EconomyBuff struct {
+	Id         string  `json:"id,omitempty"`
+	Name       string  `json:"name,omitempty"`
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// The reward content types the buff applies to, such as EconomyBuffRewardTypeCurrency.
+	RewardTypes []string `json:"reward_types,omitempty"`
+	// Optional IDs of the currencies, items, or energies the buff is limited to.
+	ContentIds   []string `json:"content_ids,omitempty"`
+	StartTimeSec int64    `json:"start_time_sec,omitempty"`
+	EndTimeSec   int64    `json:"end_time_sec,omitempty"`
+}
+
+// EconomyBuffsMultiplier returns the product of the multipliers of the buffs which are active at the given time and
+// apply to the reward content. It returns 1 if no buffs apply.
+func EconomyBuffsMultiplier(buffs []*EconomyBuff, rewardType, contentID string, nowSec int64) float64 {
+	multiplier := 1.0
+	for _, buff := range buffs {
+		if buff == nil || buff.Multiplier <= 0 {
+			continue
+		}
+		if nowSec < buff.StartTimeSec || (buff.EndTimeSec > 0 && nowSec >= buff.EndTimeSec) {
+			continue
+		}
+		if !slices.Contains(buff.RewardTypes, rewardType) {
+			continue
+		}
+		if len(buff.ContentIds) > 0 && !slices.Contains(buff.ContentIds, contentID) {
+			continue
+		}
+		multiplier *= buff.Multiplier
+	}
+	return multiplier
+}
+
+// EconomyReconcileDiscrepancy is a currency whose balance replayed from the wallet ledger differs from the live wallet.
+type EconomyReconcileDiscrepancy struct {
+	CurrencyId string `json:"currency_id,omitempty"`
+	Computed   int64  `json:"computed,omitempty"`
+	Wallet     int64  `json:"wallet,omitempty"`
+	// The ID of the first ledger entry after which the replayed balance could not reach the live balance.
+	FirstDivergentEntryId string `json:"first_divergent_entry_id,omitempty"`
+}
+
+// EconomyReconcileReport is the result of reconciling a user's wallet against their wallet ledger.
+type EconomyReconcileReport struct {
+	UserId string `json:"user_id,omitempty"`
+	// The checkpoint the ledger was replayed from, zero if the whole ledger was replayed.
+	CheckpointTimeSec int64                          `json:"checkpoint_time_sec,omitempty"`
+	EntriesReplayed   int                            `json:"entries_replayed,omitempty"`
+	Discrepancies     []*EconomyReconcileDiscrepancy `json:"discrepancies,omitempty"`
+	// True if a new checkpoint was written because the wallet was consistent.
+	CheckpointWritten bool `json:"checkpoint_written,omitempty"`
+}
+
+// The kinds of operations whose cost can be previewed.
+const (
+	EconomyCostOperationStorePurchase     = "store_purchase"
+	EconomyCostOperationProgressionPath   = "progression_path"
+	EconomyCostOperationUnlockableSpeedup = "unlockable_speedup"
+	EconomyCostOperationExchange          = "exchange"
+)
+
+// EconomyCostOperation describes an operation whose cost is previewed, such as a store purchase by item ID.
+type EconomyCostOperation struct {
+	// The kind of operation, such as EconomyCostOperationStorePurchase.
+	Type string `json:"type,omitempty"`
+	// The ID the operation applies to: a store item, a progression, an unlockable instance, or an exchange.
+	Id string `json:"id,omitempty"`
+	// The number of units, defaults to 1.
+	Quantity int64 `json:"quantity,omitempty"`
+}
+
+// EconomyCostLine is a single itemized charge of an operation, after discounts and personalization.
+type EconomyCostLine struct {
+	// A label for the charge, such as a progression path's node ID.
+	Source     string `json:"source,omitempty"`
+	CurrencyId string `json:"currency_id,omitempty"`
+	ItemId     string `json:"item_id,omitempty"`
+	Amount     int64  `json:"amount,omitempty"`
+	// The amount removed from the charge by discounts.
+	Discount int64 `json:"discount,omitempty"`
+}
+
+// EconomyCostPreview is the wallet impact an operation would have, computed without making any changes.
+type EconomyCostPreview struct {
+	Lines []*EconomyCostLine `json:"lines,omitempty"`
+	// The total charged for each currency.
+	Currencies map[string]int64 `json:"currencies,omitempty"`
+	// The total charged for each item.
+	Items map[string]int64 `json:"items,omitempty"`
+	// The user's currency balances after the operation.
+	Balances   map[string]int64 `json:"balances,omitempty"`
+	Affordable bool             `json:"affordable,omitempty"`
+	// The amount of each currency or item the user is missing when the operation is not affordable.
+	Shortfall map[string]int64 `json:"shortfall,omitempty"`
+}
+
+// An EconomyCostResolver is a system which can compute the itemized cost of its own operations for a cost preview,
+// without making any changes. Charges must already have discounts and personalization applied.
+type EconomyCostResolver interface {
+	// ResolveCost returns the itemized cost of the operation for the user.
+	ResolveCost(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, operation *EconomyCostOperation) (lines []*EconomyCostLine, err error)
+}
+
 // The EconomySystem is the foundation of a game's economy.
 //
 // It provides functionality for 4 different reward types: basic, gacha, weighted table, and custom. These rolled
@@ -207,6 +1262,11 @@ type EconomySystem interface {
 	// RewardRoll takes a reward configuration and rolls an actual reward from it, applying all appropriate rules.
 	RewardRoll(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, rewardConfig *EconomyConfigReward) (reward *Reward, err error)
 
+	// RewardPreview returns every outcome of a single weighted roll of the reward with its normalized probability,
+	// without rolling, as computed by EconomyConfigReward.Outcomes. The preview is for the given pity counters, or
+	// the user's stored pity counters if nil, so a forced pity roll only has the rare contents as outcomes.
+	RewardPreview(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, rewardConfig *EconomyConfigReward, counters EconomyPityCounters) (outcomes []*EconomyRewardOutcome, err error)
+
 	// RewardGrant updates a user's economy, inventory, and/or energy models with the contents of a rolled reward.
 	RewardGrant(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, reward *Reward, metadata map[string]interface{}, ignoreLimits bool) (newItems map[string]*InventoryItem, updatedItems map[string]*InventoryItem, notGrantedItemIDs map[string]int64, err error)
 
@@ -222,24 +1282,91 @@ type EconomySystem interface {
 	// DonationRequest will create a donation request for a given donation ID and user ID.
 	DonationRequest(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, donationID string) (donation *EconomyDonation, success bool, err error)
 
-	// List will get the defined store items and placements within the economy system.
+	// List will get the defined store items and placements within the economy system. When spend limits are configured,
+	// store items with a SKU hold the user's remaining allowance in their additional properties.
 	List(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (storeItems map[string]*EconomyConfigStoreItem, placements map[string]*EconomyConfigPlacement, rewardModifiers []*ActiveRewardModifier, timestamp int64, err error)
 
+	// OfferChainList returns the user's current step in each offer chain, including expired or completed chains.
+	OfferChainList(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (chains map[string]*EconomyOfferChain, err error)
+
 	// Grant will add currencies, and reward modifiers to a user's economy by ID.
 	Grant(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, currencies map[string]int64, items map[string]int64, modifiers []*RewardModifier, walletMetadata map[string]interface{}) (updatedWallet map[string]int64, rewardModifiers []*ActiveRewardModifier, timestamp int64, err error)
 
+	// BuffActivate gives the user a temporary buff which multiplies the amounts of the reward types it applies to in
+	// every grant until it expires. It returns the user's active buffs.
+	BuffActivate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, buff *EconomyBuff) (buffs []*EconomyBuff, err error)
+
+	// BuffList returns the user's active buffs, expired buffs are removed.
+	BuffList(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (buffs []*EconomyBuff, err error)
+
+	// Confiscate removes currencies and inventory items from a user, recording the reason and the actor from the
+	// context in the wallet ledger and an audit event. Balances only go negative if allowed in the configuration.
+	Confiscate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, adjustments *EconomyConfiscation, reason string) (updatedWallet map[string]int64, removedItems map[string]*InventoryItem, err error)
+
+	// ReservationCreate holds currencies from a user's wallet so they cannot be spent elsewhere until the reservation
+	// is committed, released, or expires.
+	ReservationCreate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, currencies map[string]int64) (reservation *EconomyReservation, err error)
+
+	// ReservationCommit spends the currencies held by a reservation, or fails with ErrReservationExpired if the
+	// reservation has passed its expiry time.
+	ReservationCommit(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, reservationID string) (updatedWallet map[string]int64, err error)
+
+	// ReservationRelease returns the currencies held by a reservation to the user's available balance.
+	ReservationRelease(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, reservationID string) (updatedWallet map[string]int64, err error)
+
+	// ReservationSweep releases all reservations which have passed their expiry time. It's run on the configured
+	// sweep interval but may also be called directly.
+	ReservationSweep(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule) (released int, err error)
+
 	// UnmarshalWallet unmarshals and returns the account's wallet as a map[string]int64.
 	UnmarshalWallet(account *api.Account) (wallet map[string]int64, err error)
 
 	// PurchaseIntent will create a purchase intent for a particular store item for a user ID.
 	PurchaseIntent(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, itemID string, store EconomyStoreType, sku string) (err error)
 
-	// PurchaseItem will validate a purchase and give the user ID the appropriate rewards.
+	// PurchaseIntentCreate will capture the resolved price, discounts, and bonuses of a store item for a user ID as a
+	// single-use intent which can be committed before it expires.
+	PurchaseIntentCreate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, itemID string, store EconomyStoreType) (intent *EconomyPurchaseIntentInfo, err error)
+
+	// PurchaseCommit will execute exactly what was captured by a purchase intent for a user ID, or fail if the intent
+	// has expired or the store item has changed since it was created.
+	PurchaseCommit(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, userID, intentID, receipt string) (updatedWallet map[string]int64, updatedInventory *Inventory, reward *Reward, isSandboxPurchase bool, err error)
+
+	// PurchaseItem will validate a purchase and give the user ID the appropriate rewards. Purchases of SKUs which would
+	// exceed the user's monthly spend limit are rejected with an EconomySpendLimitError.
 	PurchaseItem(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, userID, itemID string, store EconomyStoreType, receipt string) (updatedWallet map[string]int64, updatedInventory *Inventory, reward *Reward, isSandboxPurchase bool, err error)
 
+	// PreviewCost returns the itemized cost of an operation, the user's balances after it, and whether it's affordable,
+	// without making any changes. Store purchases and exchanges are resolved by the economy, and other operations are
+	// resolved by the owning system, found through the Hiro system registry, which implements EconomyCostResolver.
+	// Returns ErrEconomyCostOperation if no system can resolve the operation.
+	PreviewCost(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, operation *EconomyCostOperation) (preview *EconomyCostPreview, err error)
+
+	// PurchaseItemQuantity purchases a quantity of a store item with currencies, charging the total computed with the
+	// store item's price breaks, and grants its reward once for each unit.
+	PurchaseItemQuantity(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, itemID string, quantity int64) (updatedWallet map[string]int64, updatedInventory *Inventory, reward *Reward, err error)
+
 	// PurchaseRestore will process a restore attempt for the given user, based on a set of restore receipts.
 	PurchaseRestore(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, store EconomyStoreType, receipts []string) (err error)
 
+	// ListCurrencies returns the display metadata for every currency visible to the user, ordered by sort order.
+	// Currencies in the user's wallet which are not configured are returned with default metadata.
+	ListCurrencies(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (currencies []*EconomyCurrencyMetadata, err error)
+
+	// DecaySweep applies any due currency decay to all users, never reducing a balance below the configured floor.
+	// Each decay is recorded in the wallet ledger.
+	DecaySweep(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule) (decayed int, err error)
+
+	// Reconcile replays the wallet ledger of each user from their last checkpoint and compares the computed balances
+	// with the live wallet, for server-to-server use after incidents. With no user IDs it scans all users in pages of
+	// up to limit users from the cursor, so it can be run over the whole player base gradually. Set writeCheckpoint to
+	// record a new checkpoint for each user whose wallet is consistent.
+	Reconcile(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userIDs []string, limit int, cursor string, writeCheckpoint bool) (reports []*EconomyReconcileReport, nextCursor string, err error)
+
+	// MetricsExport returns the aggregate currency counters for all time buckets within the given range. The counters
+	// are updated with the same batched writes as the transactions they count.
+	MetricsExport(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, startTimeSec, endTimeSec int64) (buckets []*EconomyMetricsBucket, err error)
+
 	// PlacementStatus will get the status of a specified placement.
 	PlacementStatus(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, rewardID, placementID string, retryCount int) (resp *EconomyPlacementStatus, err error)
 
@@ -261,6 +1388,82 @@ type EconomySystem interface {
 	// SetOnPlacementReward sets a custom reward function which will run after a placement's reward is rolled.
 	SetOnPlacementReward(fn OnReward[*EconomyPlacementInfo])
 
+	// PityCounters returns the user's pity counters for rewards configured with a pity group.
+	PityCounters(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (counters EconomyPityCounters, err error)
+
+	// RewardChoiceList returns the user's pending reward choices. Expired choices are resolved to their default option
+	// first, and choices bound to live events which have ended are removed.
+	RewardChoiceList(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (choices []*EconomyRewardChoice, err error)
+
+	// RewardChoiceCleanup removes the pending reward choices bound to live events which are no longer active, without
+	// granting them. It's run by the scheduler.
+	RewardChoiceCleanup(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule) (removed int, err error)
+
+	// RewardChoiceClaim grants the selected option of a pending reward choice. Each choice can only be claimed once, and
+	// a choice bound to a live event which is no longer active fails with ErrClaimWindowClosed.
+	RewardChoiceClaim(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, choiceID string, option int) (reward *Reward, err error)
+
+	// WebOrderCreate creates a pending web shop order for a user to purchase a store item, and is called by the web
+	// shop backend. Orders which would exceed the user's monthly spend limit are rejected with an EconomySpendLimitError.
+	WebOrderCreate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, itemID string) (order *EconomyWebOrder, err error)
+
+	// WebOrderConfirm verifies the signature of a web shop payment callback and grants the store item's reward with
+	// the ledger entries flagged as external. Each order can only be confirmed once, so replayed callbacks fail with
+	// ErrEconomyWebOrderConfirmed.
+	WebOrderConfirm(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, orderID string, payload []byte, signature string) (order *EconomyWebOrder, err error)
+
+	// WebOrderGet returns the status of a web shop order.
+	WebOrderGet(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, orderID string) (order *EconomyWebOrder, err error)
+
+	// SpendLimitGet returns the user's real-money spend in the current month against their limit.
+	SpendLimitGet(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (limit *EconomySpendLimit, err error)
+
+	// SpendLimitSet sets the user's monthly spend limit, and is called server-to-server such as by a parental controls
+	// backend. A negative limit clears the user's limit so the config default applies.
+	SpendLimitSet(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, monthlyLimit int64) (limit *EconomySpendLimit, err error)
+
+	// WebOrderSweep expires web shop orders which have not been confirmed within the order expiry.
+	WebOrderSweep(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule) (expired int, err error)
+
+	// RewardCodeRedeem grants the reward of a reward code to the user. Family-scoped codes which were redeemed by another
+	// account in the same family fail with ErrEconomyRewardCodeLinked.
+	RewardCodeRedeem(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, code string) (reward *Reward, err error)
+
+	// SetFamilyResolver sets the function used to map a user to their account family for family-scoped reward codes.
+	// When no resolver is set redemptions are tracked per account.
+	SetFamilyResolver(fn FamilyResolverFn)
+
+	// SetOnRewardCodeReward sets a custom reward function which will run after a reward code's reward is rolled.
+	SetOnRewardCodeReward(fn OnReward[*EconomyConfigRewardCode])
+
+	// CampaignEnroll enrolls the user in a drip campaign. Enrolling an already enrolled user keeps their progress, and
+	// enrolling after the campaign has ended fails with ErrEconomyCampaignEnded.
+	CampaignEnroll(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, campaignID string) (progress *EconomyCampaignProgress, err error)
+
+	// CampaignUnenroll removes the user from a drip campaign, no further grants are made to them.
+	CampaignUnenroll(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, campaignID string) (err error)
+
+	// CampaignProcess grants the current day's reward of each active campaign to enrolled users who have not received
+	// it yet. It's run by the scheduler, and the user's progress is written with each grant so a day is never granted
+	// twice.
+	CampaignProcess(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule) (granted int, err error)
+
+	// DailyDealsGet returns the user's deals for the day in a daily deals placement, drawing and persisting them on the
+	// first request of the day.
+	DailyDealsGet(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, placementID string) (deals *EconomyDailyDeals, err error)
+
+	// DailyDealsPurchase purchases a daily deal's store item, or fails with ErrEconomyDailyDealSoldOut once the deal's
+	// purchase limit for the day is reached.
+	DailyDealsPurchase(ctx context.Context, logger runtime.Logger, db *sql.DB, nk runtime.NakamaModule, userID, placementID, storeItemID string, store EconomyStoreType, receipt string) (deals *EconomyDailyDeals, updatedWallet map[string]int64, updatedInventory *Inventory, reward *Reward, err error)
+
+	// DailyDealsReroll charges the reroll cost and draws a new set of deals for the day, or fails with
+	// ErrEconomyDailyDealsReroll once the daily reroll cap is reached.
+	DailyDealsReroll(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, placementID string) (deals *EconomyDailyDeals, updatedWallet map[string]int64, err error)
+
 	// SetOnStoreItemReward sets a custom reward function which will run after store item's reward is rolled.
 	SetOnStoreItemReward(fn OnReward[*EconomyConfigStoreItem])
 }
+
+// FamilyResolverFn maps a user to the ID of the family of linked accounts they belong to, such as from a linked
+// platform ID. An empty family ID falls back to tracking by the user ID.
+type FamilyResolverFn func(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (familyID string, err error)
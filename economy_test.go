@@ -0,0 +1,319 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"maps"
+	"math"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+func TestEconomyRewardChoiceClaimWindowOpen(t *testing.T) {
+	liveEvents := []*runtime.LiveEvent{
+		nil,
+		{Id: "weekend", ActiveStartTimeSec: 1000, ActiveEndTimeSec: 2000},
+		{Id: "open", ActiveStartTimeSec: 1000},
+	}
+
+	tests := []struct {
+		name        string
+		liveEventId string
+		nowSec      int64
+		want        bool
+	}{
+		{name: "unbound", nowSec: 5000, want: true},
+		{name: "active", liveEventId: "weekend", nowSec: 1500, want: true},
+		{name: "not started", liveEventId: "weekend", nowSec: 999, want: false},
+		{name: "ended", liveEventId: "weekend", nowSec: 2000, want: false},
+		{name: "no end", liveEventId: "open", nowSec: 5000, want: true},
+		{name: "missing", liveEventId: "removed", nowSec: 1500, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choice := &EconomyRewardChoice{Id: "choice", LiveEventId: tt.liveEventId}
+			if open := choice.ClaimWindowOpen(liveEvents, tt.nowSec); open != tt.want {
+				t.Errorf("claim window open = %v, want %v", open, tt.want)
+			}
+		})
+	}
+
+	choice := &EconomyRewardChoice{Id: "choice", LiveEventId: "weekend"}
+	if choice.ClaimWindowOpen([]*runtime.LiveEvent{nil}, 1500) {
+		t.Error("claim window open with only a nil live event")
+	}
+}
+
+func TestEconomyConfigRewardOutcomes(t *testing.T) {
+	reward := &EconomyConfigReward{
+		Weighted: []*EconomyConfigRewardContents{
+			{Weight: 60},
+			{Weight: 30},
+			{Weight: 10},
+		},
+		TotalWeight: 200,
+		Pity:        &EconomyConfigRewardPity{Group: "season", Rolls: 10, RareIndexes: []int{2}},
+	}
+
+	tests := []struct {
+		name     string
+		counters EconomyPityCounters
+		want     map[int]float64
+	}{
+		{name: "no counters", want: map[int]float64{0: 0.3, 1: 0.15, 2: 0.05, -1: 0.5}},
+		{name: "below pity", counters: EconomyPityCounters{"season": 8}, want: map[int]float64{0: 0.3, 1: 0.15, 2: 0.05, -1: 0.5}},
+		{name: "forced pity", counters: EconomyPityCounters{"season": 9}, want: map[int]float64{2: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcomes := reward.Outcomes(tt.counters)
+			if len(outcomes) != len(tt.want) {
+				t.Fatalf("outcomes = %d, want %d", len(outcomes), len(tt.want))
+			}
+			var sum float64
+			for _, outcome := range outcomes {
+				sum += outcome.Probability
+				if want, ok := tt.want[outcome.Index]; !ok || math.Abs(outcome.Probability-want) > 1e-9 {
+					t.Errorf("outcome %d probability = %v, want %v", outcome.Index, outcome.Probability, want)
+				}
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("probabilities sum to %v, want 1", sum)
+			}
+		})
+	}
+
+	reward.TotalWeight = 0
+	var sum float64
+	for _, outcome := range reward.Outcomes(nil) {
+		if outcome.Index < 0 {
+			t.Errorf("unexpected remainder outcome without a larger total weight")
+		}
+		sum += outcome.Probability
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("probabilities sum to %v, want 1", sum)
+	}
+}
+
+func TestEconomyConfigFormatCurrency(t *testing.T) {
+	config := &EconomyConfig{
+		Currencies: map[string]*EconomyConfigCurrency{
+			"coins": {Display: &EconomyConfigCurrencyDisplay{
+				Abbreviations: []*EconomyConfigCurrencyAbbreviation{
+					{Threshold: 1_000_000, Suffix: "M", DecimalPlaces: 1},
+					{Threshold: 1_000, Suffix: "K", DecimalPlaces: 1},
+					{Threshold: 1_000_000_000, Suffix: "B", DecimalPlaces: 2},
+				},
+			}},
+			"dollars": {Precision: 2, Display: &EconomyConfigCurrencyDisplay{Symbol: "$", DecimalPlaces: 2}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		currencyID string
+		amount     int64
+		want       string
+	}{
+		{name: "below abbreviation", currencyID: "coins", amount: 999, want: "999"},
+		{name: "thousands", currencyID: "coins", amount: 1_500, want: "1.5K"},
+		{name: "millions", currencyID: "coins", amount: 1_260_000, want: "1.3M"},
+		{name: "large amount", currencyID: "coins", amount: 7_345_000_000_000, want: "7345B"},
+		{name: "rollover to next abbreviation", currencyID: "coins", amount: 999_950, want: "1M"},
+		{name: "no rollover", currencyID: "coins", amount: 999_940, want: "999.9K"},
+		{name: "rollover across abbreviations", currencyID: "coins", amount: 999_999_999, want: "1B"},
+		{name: "negative", currencyID: "coins", amount: -999_950, want: "-1M"},
+		{name: "precision and grouping", currencyID: "dollars", amount: 123_456_789, want: "$1,234,567.89"},
+		{name: "unknown currency", currencyID: "gems", amount: 1_234_567, want: "1,234,567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if formatted := config.FormatCurrency(tt.currencyID, tt.amount); formatted != tt.want {
+				t.Errorf("formatted = %q, want %q", formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestEconomyPityCountersGroup(t *testing.T) {
+	chest := &EconomyConfigRewardPity{Group: "season", Rolls: 3, RareIndexes: []int{1}}
+	bundle := &EconomyConfigRewardPity{Group: "season", Rolls: 3, RareIndexes: []int{2}}
+	daily := &EconomyConfigRewardPity{Group: "daily", Rolls: 3, RareIndexes: []int{1}}
+
+	counters := EconomyPityCounters{}
+	counters.Record(chest, 0)
+	counters.Record(bundle, 0)
+	if counters["season"] != 2 {
+		t.Fatalf("season counter = %d, want 2 after rolls of two tables in the group", counters["season"])
+	}
+	if counters["daily"] != 0 {
+		t.Fatalf("daily counter = %d, want 0", counters["daily"])
+	}
+	if !counters.Forced(chest) || !counters.Forced(bundle) {
+		t.Fatal("next roll of the group is not forced")
+	}
+	if counters.Forced(daily) {
+		t.Fatal("roll of another group is forced")
+	}
+
+	// A rare roll from either table resets the shared counter.
+	counters.Record(bundle, 2)
+	if counters["season"] != 0 || counters.Forced(chest) {
+		t.Fatalf("season counter = %d after a rare roll, want 0", counters["season"])
+	}
+
+	// Rewards without a group share the empty group's counter.
+	counters.Record(&EconomyConfigRewardPity{Rolls: 2}, 0)
+	if !counters.Forced(&EconomyConfigRewardPity{Rolls: 2, RareIndexes: []int{3}}) {
+		t.Fatal("ungrouped rewards do not share a counter")
+	}
+
+	var none *EconomyConfigRewardPity
+	counters.Record(none, 0)
+	if counters.Forced(none) {
+		t.Fatal("roll without pity is forced")
+	}
+}
+
+func TestEconomyConfigCampaignDue(t *testing.T) {
+	const startTimeSec = 1_700_000_000
+	campaign := &EconomyConfigCampaign{StartTimeSec: startTimeSec, DurationDays: 3}
+	progress := &EconomyCampaignProgress{CampaignId: "welcome", EnrollTimeSec: startTimeSec}
+
+	tests := []struct {
+		name         string
+		lastGrantDay int
+		nowSec       int64
+		wantDay      int
+		wantDue      bool
+	}{
+		{name: "before start", nowSec: startTimeSec - 1, wantDay: 0, wantDue: false},
+		{name: "first day", nowSec: startTimeSec, wantDay: 1, wantDue: true},
+		{name: "end of first day", nowSec: startTimeSec + 86399, wantDay: 1, wantDue: true},
+		{name: "first day granted", lastGrantDay: 1, nowSec: startTimeSec + 86399, wantDay: 1, wantDue: false},
+		{name: "day boundary", lastGrantDay: 1, nowSec: startTimeSec + 86400, wantDay: 2, wantDue: true},
+		{name: "same day after grant", lastGrantDay: 2, nowSec: startTimeSec + 86400 + 3600, wantDay: 2, wantDue: false},
+		{name: "missed day", lastGrantDay: 1, nowSec: startTimeSec + 2*86400, wantDay: 3, wantDue: true},
+		{name: "after duration", lastGrantDay: 2, nowSec: startTimeSec + 3*86400, wantDay: 4, wantDue: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress.LastGrantDay = tt.lastGrantDay
+			day, due := campaign.Due(progress, tt.nowSec)
+			if day != tt.wantDay || due != tt.wantDue {
+				t.Errorf("due = %d, %v, want %d, %v", day, due, tt.wantDay, tt.wantDue)
+			}
+		})
+	}
+
+	// Recording the grant makes a second call on the same day not due.
+	progress.LastGrantDay = 0
+	day, due := campaign.Due(progress, startTimeSec+100)
+	if !due {
+		t.Fatal("first call is not due")
+	}
+	progress.LastGrantDay = day
+	if _, due = campaign.Due(progress, startTimeSec+200); due {
+		t.Fatal("second call on the same day is due")
+	}
+}
+
+func TestEconomyConfigOfflineProgressAccrue(t *testing.T) {
+	config := &EconomyConfigOfflineProgress{
+		MaxDurationSec: 3600,
+		Rates: map[string]*EconomyConfigOfflineProgressRate{
+			"base": {Currencies: map[string]float64{"coins": 0.5}},
+			"mine": {Currencies: map[string]float64{"coins": 1, "gems": 0.001}, ProgressionId: "mine_unlocked"},
+		},
+	}
+
+	progress := config.Accrue(1000, 1000+7201, nil)
+	if !progress.Capped || progress.OfflineSec != 7201 || progress.AccruedSec != config.MaxDurationSec {
+		t.Fatalf("capped = %v, offline = %d, accrued = %d, want capped at %d", progress.Capped, progress.OfflineSec, progress.AccruedSec, config.MaxDurationSec)
+	}
+	if progress.Currencies["coins"] != 1800 || len(progress.Currencies) != 1 {
+		t.Fatalf("currencies = %v, want only the ungated rate", progress.Currencies)
+	}
+
+	// Fractional amounts are rounded down.
+	progress = config.Accrue(1000, 1000+1999, func(progressionID string) bool { return progressionID == "mine_unlocked" })
+	if progress.Capped || progress.AccruedSec != 1999 {
+		t.Fatalf("capped = %v, accrued = %d, want 1999 uncapped", progress.Capped, progress.AccruedSec)
+	}
+	if progress.Currencies["coins"] != 2998 || progress.Currencies["gems"] != 1 {
+		t.Fatalf("currencies = %v, want coins 2998 and gems 1", progress.Currencies)
+	}
+
+	if progress = config.Accrue(0, 5000, nil); progress.AccruedSec != 0 || len(progress.Currencies) != 0 {
+		t.Fatalf("accrued without a last seen time = %d, %v, want nothing", progress.AccruedSec, progress.Currencies)
+	}
+}
+
+func TestEconomyConfigStoreItemCostTotal(t *testing.T) {
+	breaks := []*EconomyConfigStoreItemPriceBreak{
+		{MinQuantity: 10, Currencies: map[string]int64{"coins": 80}},
+		nil,
+		{MinQuantity: 50, Currencies: map[string]int64{"coins": 60}},
+		{MinQuantity: 5, Currencies: map[string]int64{"coins": 90}},
+	}
+	cost := &EconomyConfigStoreItemCost{Currencies: map[string]int64{"coins": 100}, PriceBreaks: breaks}
+
+	tests := []struct {
+		name     string
+		quantity int64
+		want     map[string]int64
+	}{
+		{name: "no quantity", quantity: 0, want: nil},
+		{name: "base price", quantity: 4, want: map[string]int64{"coins": 400}},
+		{name: "below break", quantity: 9, want: map[string]int64{"coins": 810}},
+		{name: "at break", quantity: 10, want: map[string]int64{"coins": 800}},
+		{name: "highest break", quantity: 60, want: map[string]int64{"coins": 3600}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if total := cost.Total(tt.quantity); !maps.Equal(total, tt.want) {
+				t.Errorf("total = %v, want %v", total, tt.want)
+			}
+		})
+	}
+}
+
+func TestEconomyBuffsMultiplier(t *testing.T) {
+	doubleCoins := &EconomyBuff{Id: "double_coins", Multiplier: 2, RewardTypes: []string{EconomyBuffRewardTypeCurrency}, ContentIds: []string{"coins"}, StartTimeSec: 1000, EndTimeSec: 2000}
+	expired := &EconomyBuff{Id: "triple_coins", Multiplier: 3, RewardTypes: []string{EconomyBuffRewardTypeCurrency}, StartTimeSec: 0, EndTimeSec: 1000}
+	gems := &EconomyBuff{Id: "double_gems", Multiplier: 2, RewardTypes: []string{EconomyBuffRewardTypeCurrency}, ContentIds: []string{"gems"}}
+	buffs := []*EconomyBuff{doubleCoins, expired, gems, nil}
+
+	const grant = 150
+	if granted := int64(float64(grant) * EconomyBuffsMultiplier(buffs, EconomyBuffRewardTypeCurrency, "coins", 1500)); granted != 2*grant {
+		t.Errorf("buffed coin grant = %d, want %d", granted, 2*grant)
+	}
+	if multiplier := EconomyBuffsMultiplier(buffs, EconomyBuffRewardTypeCurrency, "coins", 2000); multiplier != 1 {
+		t.Errorf("multiplier after the buffs expired = %v, want 1", multiplier)
+	}
+	if multiplier := EconomyBuffsMultiplier(buffs, EconomyBuffRewardTypeItem, "coins", 1500); multiplier != 1 {
+		t.Errorf("multiplier for another reward type = %v, want 1", multiplier)
+	}
+	if multiplier := EconomyBuffsMultiplier(buffs, EconomyBuffRewardTypeCurrency, "gems", 1500); multiplier != 2 {
+		t.Errorf("gems multiplier = %v, want 2 from the gems buff only", multiplier)
+	}
+}
@@ -22,7 +22,14 @@ import (
 
 // EnergyConfig is the data definition for the EnergySystem type.
 type EnergyConfig struct {
-	Energies map[string]*EnergyConfigEnergy `json:"energies,omitempty"`
+	Energies    map[string]*EnergyConfigEnergy `json:"energies,omitempty"`
+	IdleSummary *EnergyConfigIdleSummary       `json:"idle_summary,omitempty"`
+}
+
+// EnergyConfigIdleSummary enables a summary of what happened to a user's energies while they were away.
+type EnergyConfigIdleSummary struct {
+	// The minimum time since the user was last seen for a summary to be returned, so rapid polls do not repeat it.
+	MinSessionGapSec int64 `json:"min_session_gap_sec,omitempty"`
 }
 
 type EnergyConfigEnergy struct {
@@ -36,6 +43,25 @@ type EnergyConfigEnergy struct {
 	AdditionalProperties map[string]string    `json:"additional_properties,omitempty"`
 }
 
+// EnergyIdleSummary describes what happened to a user's energies between the last time they were seen and now.
+type EnergyIdleSummary struct {
+	LastSeenTimeSec int64                               `json:"last_seen_time_sec,omitempty"`
+	CurrentTimeSec  int64                               `json:"current_time_sec,omitempty"`
+	Energies        map[string]*EnergyIdleSummaryEnergy `json:"energies,omitempty"`
+	// The energy modifiers which expired while the user was away.
+	ExpiredModifiers []*RewardEnergyModifier `json:"expired_modifiers,omitempty"`
+}
+
+// EnergyIdleSummaryEnergy is the idle accrual of a single energy.
+type EnergyIdleSummaryEnergy struct {
+	// The amount regenerated while the user was away.
+	Regenerated int32 `json:"regenerated,omitempty"`
+	// The time the energy spent at its max count, when no more was regenerated.
+	TimeAtCapSec int64 `json:"time_at_cap_sec,omitempty"`
+	// The amount which would have regenerated while the energy was at its max count.
+	Overflow int32 `json:"overflow,omitempty"`
+}
+
 // The EnergySystem provides a gameplay system for Energy timers.
 //
 // An energy is a gameplay mechanic used to reward or limit progress which a player can make through the gameplay
@@ -46,6 +72,10 @@ type EnergySystem interface {
 	// Get returns all energies defined and the values a user currently owns by ID.
 	Get(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (energies map[string]*Energy, err error)
 
+	// GetWithIdleSummary returns the same as Get, and a summary of energy accrued since the user was last seen. The
+	// summary is nil if the user was seen more recently than the configured minimum session gap.
+	GetWithIdleSummary(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (energies map[string]*Energy, summary *EnergyIdleSummary, err error)
+
 	// Spend will deduct the amounts from each energy for a user by ID.
 	Spend(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, amounts map[string]int32) (energies map[string]*Energy, reward *Reward, err error)
 
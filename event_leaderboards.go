@@ -16,10 +16,37 @@ package hiro
 
 import (
 	"context"
+	"math"
+	"slices"
+	"strconv"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+var (
+	ErrEventLeaderboardInviteInvalid   = runtime.NewError("event leaderboard invite code invalid", 3)      // INVALID_ARGUMENT
+	ErrEventLeaderboardInviteExpired   = runtime.NewError("event leaderboard invite code expired", 9)      // FAILED_PRECONDITION
+	ErrEventLeaderboardCohortFull      = runtime.NewError("event leaderboard cohort full", 9)              // FAILED_PRECONDITION
+	ErrEventLeaderboardAlreadyInCohort = runtime.NewError("event leaderboard already joined", 9)           // FAILED_PRECONDITION
+	ErrEventLeaderboardPrivateDisabled = runtime.NewError("event leaderboard private cohorts disabled", 3) // INVALID_ARGUMENT
+	ErrEventLeaderboardScoreDenied     = runtime.NewError("event leaderboard score denied", 7)             // PERMISSION_DENIED
+	ErrEventLeaderboardNotFlagged      = runtime.NewError("event leaderboard record not flagged", 3)       // INVALID_ARGUMENT
+	ErrEventLeaderboardRewardsInvalid  = runtime.NewError("event leaderboard rewards invalid", 3)          // INVALID_ARGUMENT
+)
+
+// EventLeaderboardRecordMetadataFlagged is the reserved record metadata key which holds the reason a score was flagged.
+// Rewards are withheld from flagged records until they are reviewed.
+const EventLeaderboardRecordMetadataFlagged = "hiro_flagged"
+
+// The decisions an OnEventLeaderboardScore function can make about a score submission.
+const (
+	EventLeaderboardScoreAllow = "allow"
+	// EventLeaderboardScoreFlag accepts the score but withholds the record's reward pending review.
+	EventLeaderboardScoreFlag = "flag"
+	// EventLeaderboardScoreDeny rejects the score with ErrEventLeaderboardScoreDenied.
+	EventLeaderboardScoreDeny = "deny"
+)
+
 // EventLeaderboardsConfig is the data definition for the EventLeaderboardsSystem type.
 type EventLeaderboardsConfig struct {
 	EventLeaderboards map[string]*EventLeaderboardsConfigLeaderboard `json:"event_leaderboards,omitempty"`
@@ -42,11 +69,388 @@ type EventLeaderboardsConfigLeaderboard struct {
 	StartTimeSec         int64                                                      `json:"start_time_sec,omitempty"`
 	EndTimeSec           int64                                                      `json:"end_time_sec,omitempty"`
 	Duration             int64                                                      `json:"duration,omitempty"`
+	PrivateCohorts       *EventLeaderboardsConfigPrivateCohorts                     `json:"private_cohorts,omitempty"`
+	TiePolicy            string                                                     `json:"tie_policy,omitempty" hirovalidate:"oneof=subscore|shared,policy=default"`
+	Collusion            *EventLeaderboardsConfigCollusion                          `json:"collusion,omitempty"`
+	SkillMatchmaking     *EventLeaderboardsConfigSkillMatchmaking                   `json:"skill_matchmaking,omitempty"`
+	RewardValidation     *EventLeaderboardsConfigRewardValidation                   `json:"reward_validation,omitempty"`
+	ScoreDecay           *EventLeaderboardsConfigScoreDecay                         `json:"score_decay,omitempty"`
+	ScoreBuffer          *EventLeaderboardsConfigScoreBuffer                        `json:"score_buffer,omitempty"`
 
 	BackingId           string `json:"-"`
 	CalculatedBackingId string `json:"-"`
 }
 
+const (
+	// EventLeaderboardTiePolicySubscore ranks tied scores by subscore and then by who reached the score first. This is
+	// the default policy.
+	EventLeaderboardTiePolicySubscore = "subscore"
+	// EventLeaderboardTiePolicyShared gives users with the same score and subscore the same rank, and so the same reward.
+	EventLeaderboardTiePolicyShared = "shared"
+)
+
+// EventLeaderboardRecordMetadataRawScore is the reserved record metadata key which holds the raw submitted score when
+// score decay is configured, while the record's score is the effective score used for ranking.
+const EventLeaderboardRecordMetadataRawScore = "hiro_raw_score"
+
+// The curves which can weigh scores within an event.
+const (
+	// EventLeaderboardScoreDecayExponential halves the weight of a score every half-life after it's submitted.
+	EventLeaderboardScoreDecayExponential = "exponential"
+	// EventLeaderboardScoreDecayLinear reduces the weight of a score evenly from the max weight when submitted to the
+	// min weight after the duration.
+	EventLeaderboardScoreDecayLinear = "linear"
+	// EventLeaderboardScoreDecayRamp weighs a score by when it's submitted in the event, from the min weight at the
+	// start to the max weight at the end, so scores matter more near the end.
+	EventLeaderboardScoreDecayRamp = "ramp"
+)
+
+// EventLeaderboardsConfigScoreDecay weighs scores within an event to compute the effective score used for ranking. The
+// raw submitted score is never changed and is kept in the record metadata.
+type EventLeaderboardsConfigScoreDecay struct {
+	// The decay curve, one of "exponential", "linear", or "ramp".
+	Curve string `json:"curve,omitempty" hirovalidate:"oneof=exponential|linear|ramp,policy=reject"`
+	// The number of seconds after which an exponentially decayed score has half its weight.
+	HalfLifeSec int64 `json:"half_life_sec,omitempty"`
+	// The number of seconds after which a linearly decayed score reaches the min weight.
+	DurationSec int64 `json:"duration_sec,omitempty"`
+	// The lowest weight of a score, from 0 to 1, defaults to 0.
+	MinWeight float64 `json:"min_weight,omitempty" hirovalidate:"min=0,max=1,policy=clamp"`
+	// The highest weight of a score, defaults to 1.
+	MaxWeight float64 `json:"max_weight,omitempty"`
+}
+
+// Weight returns the weight of a score submitted at the given time in an event, evaluated at the current time.
+func (c *EventLeaderboardsConfigScoreDecay) Weight(submitTimeSec, startTimeSec, endTimeSec, nowSec int64) float64 {
+	if c == nil {
+		return 1
+	}
+	maxWeight := c.MaxWeight
+	if maxWeight <= 0 {
+		maxWeight = 1
+	}
+	minWeight := min(max(c.MinWeight, 0), maxWeight)
+
+	var weight float64
+	switch c.Curve {
+	case EventLeaderboardScoreDecayExponential:
+		if c.HalfLifeSec <= 0 {
+			return maxWeight
+		}
+		age := float64(max(nowSec-submitTimeSec, 0))
+		weight = maxWeight * math.Pow(0.5, age/float64(c.HalfLifeSec))
+	case EventLeaderboardScoreDecayLinear:
+		if c.DurationSec <= 0 {
+			return maxWeight
+		}
+		progress := min(float64(max(nowSec-submitTimeSec, 0))/float64(c.DurationSec), 1)
+		weight = maxWeight - (maxWeight-minWeight)*progress
+	case EventLeaderboardScoreDecayRamp:
+		if endTimeSec <= startTimeSec {
+			return maxWeight
+		}
+		progress := min(max(float64(submitTimeSec-startTimeSec)/float64(endTimeSec-startTimeSec), 0), 1)
+		weight = minWeight + (maxWeight-minWeight)*progress
+	default:
+		return 1
+	}
+	return max(weight, minWeight)
+}
+
+// EffectiveScore returns the score used for ranking a raw score submitted at the given time in an event.
+func (c *EventLeaderboardsConfigScoreDecay) EffectiveScore(rawScore, submitTimeSec, startTimeSec, endTimeSec, nowSec int64) int64 {
+	return int64(math.Round(float64(rawScore) * c.Weight(submitTimeSec, startTimeSec, endTimeSec, nowSec)))
+}
+
+// EventLeaderboardsConfigScoreBuffer defers score writes under burst load, such as at the end of a match. Each
+// submission is added to the user's pending score, and the aggregate is written to the leaderboard once per flush
+// interval or when the user reads the event leaderboard, so many submissions cost a single read-modify-write.
+type EventLeaderboardsConfigScoreBuffer struct {
+	// How often pending scores are written to the leaderboard.
+	FlushIntervalMs int64 `json:"flush_interval_ms,omitempty"`
+	// The number of pending submissions for a user which forces a flush, zero for no limit.
+	MaxPending int `json:"max_pending,omitempty"`
+}
+
+// EventLeaderboardPendingScore is a user's aggregate of the score submissions not yet written to the leaderboard. It's
+// aggregated with the leaderboard's operator so a flush has the same result as writing each submission.
+type EventLeaderboardPendingScore struct {
+	Score    int64 `json:"score,omitempty"`
+	Subscore int64 `json:"subscore,omitempty"`
+	// The number of submissions aggregated.
+	Count        int   `json:"count,omitempty"`
+	FirstTimeSec int64 `json:"first_time_sec,omitempty"`
+	LastTimeSec  int64 `json:"last_time_sec,omitempty"`
+}
+
+// Add aggregates a score submission. Increments and decrements are summed, "set" keeps the latest submission, and
+// "best" keeps the better submission by score and then subscore.
+func (p *EventLeaderboardPendingScore) Add(operator string, ascending bool, score, subscore, nowSec int64) {
+	p.merge(operator, ascending, &EventLeaderboardPendingScore{Score: score, Subscore: subscore, Count: 1, FirstTimeSec: nowSec, LastTimeSec: nowSec})
+}
+
+// Restore returns the pending score of a failed flush to the buffer so no submissions are lost. Submissions added since
+// the flush began are kept as the more recent ones.
+func (p *EventLeaderboardPendingScore) Restore(operator string, ascending bool, failed *EventLeaderboardPendingScore) {
+	if failed == nil || failed.Count == 0 {
+		return
+	}
+	if p.Count == 0 {
+		*p = *failed
+		return
+	}
+	latest := *p
+	*p = *failed
+	p.merge(operator, ascending, &latest)
+}
+
+func (p *EventLeaderboardPendingScore) merge(operator string, ascending bool, next *EventLeaderboardPendingScore) {
+	if p.Count == 0 {
+		*p = *next
+		return
+	}
+	switch operator {
+	case "incr", "increment", "decr", "decrement":
+		p.Score += next.Score
+		p.Subscore += next.Subscore
+	case "set":
+		p.Score, p.Subscore = next.Score, next.Subscore
+	default:
+		if eventLeaderboardScoreBetter(ascending, next.Score, next.Subscore, p.Score, p.Subscore) {
+			p.Score, p.Subscore = next.Score, next.Subscore
+		}
+	}
+	p.Count += next.Count
+	p.FirstTimeSec = min(p.FirstTimeSec, next.FirstTimeSec)
+	p.LastTimeSec = max(p.LastTimeSec, next.LastTimeSec)
+}
+
+// Apply returns the score and subscore the user would have after the pending score is flushed onto their stored score,
+// so reads reflect the user's own unflushed submissions.
+func (p *EventLeaderboardPendingScore) Apply(operator string, ascending bool, score, subscore int64) (int64, int64) {
+	if p == nil || p.Count == 0 {
+		return score, subscore
+	}
+	switch operator {
+	case "incr", "increment":
+		return score + p.Score, subscore + p.Subscore
+	case "decr", "decrement":
+		return score - p.Score, subscore - p.Subscore
+	case "set":
+		return p.Score, p.Subscore
+	default:
+		if eventLeaderboardScoreBetter(ascending, p.Score, p.Subscore, score, subscore) {
+			return p.Score, p.Subscore
+		}
+		return score, subscore
+	}
+}
+
+func eventLeaderboardScoreBetter(ascending bool, score, subscore, otherScore, otherSubscore int64) bool {
+	if score == otherScore {
+		if ascending {
+			return subscore < otherSubscore
+		}
+		return subscore > otherSubscore
+	}
+	if ascending {
+		return score < otherScore
+	}
+	return score > otherScore
+}
+
+// EventLeaderboardsConfigPrivateCohorts allows friends to compete in a private cohort joined with an invite code.
+type EventLeaderboardsConfigPrivateCohorts struct {
+	// The maximum number of users who can join a private cohort, defaults to the cohort size.
+	MaxSize int `json:"max_size,omitempty"`
+	// Optional reward tiers used instead of the event leaderboard's reward tiers, to reduce rewards in private cohorts.
+	RewardTiers map[string][]*EventLeaderboardsConfigLeaderboardRewardTier `json:"reward_tiers,omitempty"`
+	// If true no rewards are granted to users in private cohorts.
+	DisableRewards bool `json:"disable_rewards,omitempty"`
+}
+
+// EventLeaderboardsConfigCollusion configures the built-in heuristic which flags suspicious score submissions, such as
+// cohort members dumping points to one player.
+type EventLeaderboardsConfigCollusion struct {
+	// The maximum score which can be gained in a single submission, zero is unlimited.
+	MaxScoreDelta int64 `json:"max_score_delta,omitempty"`
+	// The maximum share of the cohort's total score held by a single user, zero is unlimited.
+	MaxCohortShare float64 `json:"max_cohort_share,omitempty"`
+	// If true submissions which exceed a limit are denied rather than flagged.
+	Deny bool `json:"deny,omitempty"`
+}
+
+// EventLeaderboardsConfigSkillMatchmaking groups users into cohorts with others of similar skill, as measured by a
+// stat such as MMR, instead of at random. Users without the stat are matched at random.
+type EventLeaderboardsConfigSkillMatchmaking struct {
+	// The name of the stat which measures skill.
+	Stat string `json:"stat,omitempty"`
+	// If true the private stat is used, otherwise the public stat.
+	Private bool `json:"private,omitempty"`
+	// The range of stat values grouped together, users are only matched with others in the same bucket.
+	BucketSize int64 `json:"bucket_size,omitempty"`
+}
+
+// EventLeaderboardMatchmakerPropertySkillBucket is the reserved matchmaker property which holds the user's skill bucket
+// when skill matchmaking is configured, and is passed to any custom cohort selection function.
+const EventLeaderboardMatchmakerPropertySkillBucket = "hiro_skill_bucket"
+
+// SkillBucket returns the skill bucket for a stat value, or an empty bucket if the user has no value for the stat and
+// should be matched at random.
+func (c *EventLeaderboardsConfigSkillMatchmaking) SkillBucket(value int64, found bool) string {
+	if c == nil || !found {
+		return ""
+	}
+	bucketSize := c.BucketSize
+	if bucketSize <= 0 {
+		bucketSize = 1
+	}
+	bucket := value / bucketSize
+	if value < 0 && value%bucketSize != 0 {
+		// Round towards negative infinity so negative values are bucketed consistently.
+		bucket--
+	}
+	return strconv.FormatInt(bucket, 10)
+}
+
+// EventLeaderboardRewardValidationMetric is the counter incremented each time personalized reward tiers fail validation
+// and the base reward tiers are used instead. It's tagged with the event leaderboard ID and the failing tier.
+const EventLeaderboardRewardValidationMetric = "hiro_event_leaderboard_reward_validation_failed"
+
+// The reasons reward tiers can fail validation.
+const (
+	EventLeaderboardRewardInvalidCurrency    = "unknown_currency"
+	EventLeaderboardRewardInvalidItem        = "unknown_item"
+	EventLeaderboardRewardInvalidMonotonic   = "not_monotonic"
+	EventLeaderboardRewardInvalidTierNumeric = "tier_not_numeric"
+)
+
+// EventLeaderboardsConfigRewardValidation checks the reward tiers of an event leaderboard once personalization has been
+// applied. If the personalized reward tiers fail validation they're rejected and the base reward tiers are used, with
+// a log and EventLeaderboardRewardValidationMetric identifying the failing tier. Validation runs once per fetch of the
+// personalized config, not per request.
+type EventLeaderboardsConfigRewardValidation struct {
+	// If true better ranks, and the same ranks in higher tiers, must not receive strictly less total value.
+	Monotonic bool `json:"monotonic,omitempty"`
+	// The value of one unit of each currency and item used to total the guaranteed contents of a reward. Contents
+	// without a value are not counted.
+	CurrencyValues map[string]float64 `json:"currency_values,omitempty"`
+	ItemValues     map[string]float64 `json:"item_values,omitempty"`
+}
+
+// EventLeaderboardRewardValidationError identifies the reward tier which failed validation.
+type EventLeaderboardRewardValidationError struct {
+	Tier     string
+	TierName string
+	Reason   string
+	// The currency or item ID, if the reason is an unknown reference.
+	ContentId string
+}
+
+func (e *EventLeaderboardRewardValidationError) Error() string {
+	msg := ErrEventLeaderboardRewardsInvalid.Error() + ": tier " + e.Tier
+	if e.TierName != "" {
+		msg += " (" + e.TierName + ")"
+	}
+	msg += " " + e.Reason
+	if e.ContentId != "" {
+		msg += " " + e.ContentId
+	}
+	return msg
+}
+
+func (e *EventLeaderboardRewardValidationError) Unwrap() error {
+	return ErrEventLeaderboardRewardsInvalid
+}
+
+// Value returns the total value of the guaranteed contents of a reward, counting the minimum amount of each currency
+// and item.
+func (c *EventLeaderboardsConfigRewardValidation) Value(reward *EconomyConfigReward) float64 {
+	if c == nil || reward == nil || reward.Guaranteed == nil {
+		return 0
+	}
+	var value float64
+	for currencyID, currency := range reward.Guaranteed.Currencies {
+		if currency != nil {
+			value += float64(currency.Min) * c.CurrencyValues[currencyID]
+		}
+	}
+	for itemID, item := range reward.Guaranteed.Items {
+		if item != nil {
+			value += float64(item.Min) * c.ItemValues[itemID]
+		}
+	}
+	return value
+}
+
+// ValidateRewardTiers checks every reward tier references currencies and items which exist, and if configured that
+// rewards are monotonic in rank and tier. A nil function skips the reference check for that content type. It returns
+// an *EventLeaderboardRewardValidationError for the first failing tier, in tier order.
+func (c *EventLeaderboardsConfigLeaderboard) ValidateRewardTiers(currencyExists, itemExists func(id string) bool) error {
+	if c == nil || c.RewardValidation == nil {
+		return nil
+	}
+
+	tiers := make([]int, 0, len(c.RewardTiers))
+	for tier := range c.RewardTiers {
+		tierNum, err := strconv.Atoi(tier)
+		if err != nil {
+			return &EventLeaderboardRewardValidationError{Tier: tier, Reason: EventLeaderboardRewardInvalidTierNumeric}
+		}
+		tiers = append(tiers, tierNum)
+	}
+	slices.Sort(tiers)
+
+	// The best reward value of the previous tier, which the best reward of each higher tier must match or exceed.
+	previousBest := -1.0
+	for _, tierNum := range tiers {
+		tier := strconv.Itoa(tierNum)
+		rewardTiers := slices.Clone(c.RewardTiers[tier])
+		rewardTiers = slices.DeleteFunc(rewardTiers, func(rewardTier *EventLeaderboardsConfigLeaderboardRewardTier) bool {
+			return rewardTier == nil
+		})
+		slices.SortStableFunc(rewardTiers, func(a, b *EventLeaderboardsConfigLeaderboardRewardTier) int {
+			return a.RankMin - b.RankMin
+		})
+
+		best := -1.0
+		previous := -1.0
+		for _, rewardTier := range rewardTiers {
+			if rewardTier.Reward != nil && rewardTier.Reward.Guaranteed != nil {
+				for currencyID := range rewardTier.Reward.Guaranteed.Currencies {
+					if currencyExists != nil && !currencyExists(currencyID) {
+						return &EventLeaderboardRewardValidationError{Tier: tier, TierName: rewardTier.Name, Reason: EventLeaderboardRewardInvalidCurrency, ContentId: currencyID}
+					}
+				}
+				for itemID := range rewardTier.Reward.Guaranteed.Items {
+					if itemExists != nil && !itemExists(itemID) {
+						return &EventLeaderboardRewardValidationError{Tier: tier, TierName: rewardTier.Name, Reason: EventLeaderboardRewardInvalidItem, ContentId: itemID}
+					}
+				}
+			}
+
+			if !c.RewardValidation.Monotonic {
+				continue
+			}
+			value := c.RewardValidation.Value(rewardTier.Reward)
+			if previous >= 0 && value > previous {
+				// A worse rank receives more than a better rank in the same tier.
+				return &EventLeaderboardRewardValidationError{Tier: tier, TierName: rewardTier.Name, Reason: EventLeaderboardRewardInvalidMonotonic}
+			}
+			previous = value
+			best = max(best, value)
+		}
+
+		if c.RewardValidation.Monotonic && best >= 0 {
+			if best < previousBest {
+				return &EventLeaderboardRewardValidationError{Tier: tier, Reason: EventLeaderboardRewardInvalidMonotonic}
+			}
+			previousBest = best
+		}
+	}
+	return nil
+}
+
 type EventLeaderboardsConfigLeaderboardRewardTier struct {
 	Name       string               `json:"name,omitempty"`
 	RankMax    int                  `json:"rank_max,omitempty"`
@@ -61,6 +465,33 @@ type EventLeaderboardsConfigChangeZone struct {
 	DemoteIdle bool    `json:"demote_idle,omitempty"`
 }
 
+// EventLeaderboardPrivateCohort is a private cohort in the current iteration of an event leaderboard.
+type EventLeaderboardPrivateCohort struct {
+	// The invite code used to join the cohort.
+	Code               string `json:"code,omitempty"`
+	EventLeaderboardId string `json:"event_leaderboard_id,omitempty"`
+	CohortId           string `json:"cohort_id,omitempty"`
+	CreatorId          string `json:"creator_id,omitempty"`
+	MaxSize            int    `json:"max_size,omitempty"`
+	// The invite code expires when the cohort is full or the event leaderboard iteration ends.
+	ExpiryTimeSec int64 `json:"expiry_time_sec,omitempty"`
+}
+
+// EventLeaderboardRewardPreview is the reward a cohort participant would receive if the cohort closed with the current
+// standings.
+type EventLeaderboardRewardPreview struct {
+	Rank     int64  `json:"rank,omitempty"`
+	OwnerId  string `json:"owner_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Score    int64  `json:"score,omitempty"`
+	Subscore int64  `json:"subscore,omitempty"`
+	// The name of the reward tier resolved for the rank, empty if no tier matches.
+	TierName   string `json:"tier_name,omitempty"`
+	TierChange int    `json:"tier_change,omitempty"`
+	// The reward configuration which would be rolled for the participant, it is not rolled or granted by a preview.
+	Reward *EconomyConfigReward `json:"reward,omitempty"`
+}
+
 // An EventLeaderboardsSystem is a gameplay system which represents cohort-segmented, tier-based event leaderboards.
 type EventLeaderboardsSystem interface {
 	System
@@ -74,15 +505,42 @@ type EventLeaderboardsSystem interface {
 	// RollEventLeaderboard places the user into a new cohort for the specified event leaderboard if possible.
 	RollEventLeaderboard(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, eventLeaderboardID string, tier *int, matchmakerProperties map[string]interface{}) (eventLeaderboard *EventLeaderboard, err error)
 
+	// CreatePrivateCohort places the user into a new private cohort for the specified event leaderboard and returns an
+	// invite code others can use to join it.
+	CreatePrivateCohort(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, eventLeaderboardID string) (cohort *EventLeaderboardPrivateCohort, eventLeaderboard *EventLeaderboard, err error)
+
+	// JoinPrivateCohort places the user into the private cohort for the invite code instead of using matchmaking. It
+	// fails if the user already has a cohort in the current iteration of the event leaderboard.
+	JoinPrivateCohort(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, code string) (eventLeaderboard *EventLeaderboard, err error)
+
+	// FlushEventLeaderboardScores writes the user's pending buffered scores to their event leaderboards, and returns
+	// the number of event leaderboards written. Pending scores which fail to be written are kept for the next flush.
+	FlushEventLeaderboardScores(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (flushed int, err error)
+
 	// UpdateEventLeaderboard updates the user's score in the specified event leaderboard, and returns the user's updated cohort information.
+	// When score decay is configured, the cohort is ranked by effective score and each raw score is kept in its record metadata.
+	// When a score buffer is configured, the score is added to the user's pending score and the returned cohort includes it.
 	UpdateEventLeaderboard(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, username, eventLeaderboardID string, score, subscore int64, metadata map[string]interface{}) (eventLeaderboard *EventLeaderboard, err error)
 
 	// ClaimEventLeaderboard claims the user's reward for the given event leaderboard.
 	ClaimEventLeaderboard(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, eventLeaderboardID string) (eventLeaderboard *EventLeaderboard, err error)
 
+	// PreviewRewards returns the reward each participant of the cohort would receive based on the current standings,
+	// using the same tier resolution and tie policy as the distribution when the event leaderboard ends. No rewards
+	// are granted.
+	PreviewRewards(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, eventLeaderboardID, cohortID string) (previews []*EventLeaderboardRewardPreview, err error)
+
 	// SetOnEventLeaderboardsReward sets a custom reward function which will run after an event leaderboard's reward is rolled.
 	SetOnEventLeaderboardsReward(fn OnReward[*EventLeaderboardsConfigLeaderboard])
 
+	// SetOnEventLeaderboardScore sets a custom function which analyses each score submission and can flag or deny it.
+	// When no function is set the built-in heuristic is used for event leaderboards with a collusion configuration.
+	SetOnEventLeaderboardScore(fn OnEventLeaderboardScore)
+
+	// ReviewFlaggedScore clears the flag from a user's record after review so its reward is granted, or removes the
+	// record's reward if the flag is upheld.
+	ReviewFlaggedScore(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, eventLeaderboardID, cohortID string, approve bool) (err error)
+
 	// SetOnEventLeaderboardCohortSelection sets a custom function that can replace the cohort or opponent selection feature of event leaderboards.
 	SetOnEventLeaderboardCohortSelection(fn OnEventLeaderboardCohortSelection)
 
@@ -101,3 +559,57 @@ type EventLeaderboardCohortConfig struct {
 }
 
 type OnEventLeaderboardCohortSelection func(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, storageIndex string, eventID string, config *EventLeaderboardsConfigLeaderboard, userID string, tier int, matchmakerProperties map[string]interface{}) (cohortID string, cohortUserIDs []string, newCohort *EventLeaderboardCohortConfig, err error)
+
+// EventLeaderboardScoreSubmission is a previous score submission made by a user to an event leaderboard.
+type EventLeaderboardScoreSubmission struct {
+	ScoreDelta    int64 `json:"score_delta,omitempty"`
+	SubscoreDelta int64 `json:"subscore_delta,omitempty"`
+	TimeSec       int64 `json:"time_sec,omitempty"`
+}
+
+// EventLeaderboardScoreContext describes a score submission and the cohort it is made in.
+type EventLeaderboardScoreContext struct {
+	EventLeaderboardId string
+	CohortId           string
+	UserId             string
+	// True if the cohort is a private cohort joined by invite code.
+	Private bool
+	// The score gained by this submission, and the user's score after it.
+	ScoreDelta int64
+	Score      int64
+	Subscore   int64
+	// The sum of the scores of all users in the cohort, including this submission.
+	CohortTotalScore int64
+	// The user's recent submissions in the current iteration, newest first.
+	RecentSubmissions []*EventLeaderboardScoreSubmission
+	Config            *EventLeaderboardsConfigLeaderboard
+}
+
+// OnEventLeaderboardScore analyses a score submission and returns whether to allow, flag, or deny it, along with the
+// reason recorded on flagged records.
+type OnEventLeaderboardScore func(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, score *EventLeaderboardScoreContext) (decision, reason string, err error)
+
+var _ OnEventLeaderboardScore = EventLeaderboardCollusionHeuristic
+
+// EventLeaderboardCollusionHeuristic is the built-in OnEventLeaderboardScore function, which checks each submission
+// against the event leaderboard's collusion configuration.
+func EventLeaderboardCollusionHeuristic(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, score *EventLeaderboardScoreContext) (string, string, error) {
+	if score == nil || score.Config == nil || score.Config.Collusion == nil {
+		return EventLeaderboardScoreAllow, "", nil
+	}
+	collusion := score.Config.Collusion
+
+	decision := EventLeaderboardScoreFlag
+	if collusion.Deny {
+		decision = EventLeaderboardScoreDeny
+	}
+
+	if collusion.MaxScoreDelta > 0 && score.ScoreDelta > collusion.MaxScoreDelta {
+		return decision, "max_score_delta", nil
+	}
+	if collusion.MaxCohortShare > 0 && score.CohortTotalScore > 0 && float64(score.Score)/float64(score.CohortTotalScore) > collusion.MaxCohortShare {
+		return decision, "max_cohort_share", nil
+	}
+
+	return EventLeaderboardScoreAllow, "", nil
+}
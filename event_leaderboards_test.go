@@ -0,0 +1,88 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEventLeaderboardsConfigScoreDecay(t *testing.T) {
+	tests := []struct {
+		name          string
+		decay         *EventLeaderboardsConfigScoreDecay
+		submitTimeSec int64
+		nowSec        int64
+		wantWeight    float64
+		wantScore     int64
+	}{
+		{name: "nil config", submitTimeSec: 1000, nowSec: 5000, wantWeight: 1, wantScore: 1000},
+		{name: "exponential when submitted", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayExponential, HalfLifeSec: 3600}, submitTimeSec: 1000, nowSec: 1000, wantWeight: 1, wantScore: 1000},
+		{name: "exponential at one half-life", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayExponential, HalfLifeSec: 3600}, submitTimeSec: 1000, nowSec: 4600, wantWeight: 0.5, wantScore: 500},
+		{name: "exponential at two half-lives", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayExponential, HalfLifeSec: 3600}, submitTimeSec: 1000, nowSec: 8200, wantWeight: 0.25, wantScore: 250},
+		{name: "linear halfway", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayLinear, DurationSec: 1000, MinWeight: 0.2}, submitTimeSec: 1000, nowSec: 1500, wantWeight: 0.6, wantScore: 600},
+		{name: "linear past duration", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayLinear, DurationSec: 1000, MinWeight: 0.2}, submitTimeSec: 1000, nowSec: 9000, wantWeight: 0.2, wantScore: 200},
+		{name: "ramp at start", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayRamp, MinWeight: 0.5, MaxWeight: 2}, submitTimeSec: 0, nowSec: 5000, wantWeight: 0.5, wantScore: 500},
+		{name: "ramp at end", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayRamp, MinWeight: 0.5, MaxWeight: 2}, submitTimeSec: 10000, nowSec: 10000, wantWeight: 2, wantScore: 2000},
+		{name: "ramp after end", decay: &EventLeaderboardsConfigScoreDecay{Curve: EventLeaderboardScoreDecayRamp, MinWeight: 0.5, MaxWeight: 2}, submitTimeSec: 12000, nowSec: 12000, wantWeight: 2, wantScore: 2000},
+	}
+
+	const startTimeSec, endTimeSec = 0, 10000
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if weight := tt.decay.Weight(tt.submitTimeSec, startTimeSec, endTimeSec, tt.nowSec); math.Abs(weight-tt.wantWeight) > 1e-9 {
+				t.Errorf("weight = %v, want %v", weight, tt.wantWeight)
+			}
+			if score := tt.decay.EffectiveScore(1000, tt.submitTimeSec, startTimeSec, endTimeSec, tt.nowSec); score != tt.wantScore {
+				t.Errorf("effective score = %d, want %d", score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestEventLeaderboardsConfigSkillMatchmakingSkillBucket(t *testing.T) {
+	skill := &EventLeaderboardsConfigSkillMatchmaking{Stat: "rating", BucketSize: 100}
+
+	tests := []struct {
+		name  string
+		value int64
+		found bool
+		want  string
+	}{
+		{name: "missing stat", value: 0, found: false, want: ""},
+		{name: "zero", value: 0, found: true, want: "0"},
+		{name: "low", value: 99, found: true, want: "0"},
+		{name: "high", value: 1850, found: true, want: "18"},
+		{name: "negative", value: -1, found: true, want: "-1"},
+		{name: "negative boundary", value: -100, found: true, want: "-1"},
+		{name: "negative below boundary", value: -101, found: true, want: "-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if bucket := skill.SkillBucket(tt.value, tt.found); bucket != tt.want {
+				t.Errorf("bucket = %q, want %q", bucket, tt.want)
+			}
+		})
+	}
+
+	if skill.SkillBucket(99, true) == skill.SkillBucket(1850, true) {
+		t.Error("high and low values share a bucket")
+	}
+	var unconfigured *EventLeaderboardsConfigSkillMatchmaking
+	if bucket := unconfigured.SkillBucket(1850, true); bucket != "" {
+		t.Errorf("bucket without skill matchmaking = %q, want empty", bucket)
+	}
+}
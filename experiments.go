@@ -0,0 +1,110 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"hash/fnv"
+	"slices"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var (
+	ErrExperimentNotFound      = runtime.NewError("experiment not found", 3)      // INVALID_ARGUMENT
+	ErrExperimentBucketInvalid = runtime.NewError("experiment bucket invalid", 3) // INVALID_ARGUMENT
+)
+
+// BaseSystemConfigExperiment is an A/B experiment which users are assigned a bucket in on their first evaluation.
+type BaseSystemConfigExperiment struct {
+	Buckets []*BaseSystemConfigExperimentBucket `json:"buckets,omitempty"`
+}
+
+// BaseSystemConfigExperimentBucket is a variant of an experiment, assigned in proportion to its weight.
+type BaseSystemConfigExperimentBucket struct {
+	Name   string `json:"name,omitempty"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+// Experiment is a user's persisted assignment in an experiment. Once assigned the bucket is reused for every later
+// evaluation, even if the experiment's buckets or weights change.
+type Experiment struct {
+	Id            string `json:"id,omitempty"`
+	Bucket        string `json:"bucket,omitempty"`
+	AssignTimeSec int64  `json:"assign_time_sec,omitempty"`
+	// True if the bucket was set with an override, such as for QA, rather than assigned.
+	Forced bool `json:"forced,omitempty"`
+}
+
+// AssignBucket chooses the bucket for a user's first evaluation of the experiment. The choice is a weighted hash of
+// the user and experiment IDs, so it does not depend on any other state. An empty bucket is returned if the
+// experiment has no buckets with a positive weight.
+func (c *BaseSystemConfigExperiment) AssignBucket(userID, experimentID string) string {
+	if c == nil {
+		return ""
+	}
+	var totalWeight uint64
+	for _, bucket := range c.Buckets {
+		if bucket != nil && bucket.Weight > 0 {
+			totalWeight += uint64(bucket.Weight)
+		}
+	}
+	if totalWeight == 0 {
+		return ""
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(experimentID))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(userID))
+	point := h.Sum64() % totalWeight
+
+	for _, bucket := range c.Buckets {
+		if bucket == nil || bucket.Weight <= 0 {
+			continue
+		}
+		if point < uint64(bucket.Weight) {
+			return bucket.Name
+		}
+		point -= uint64(bucket.Weight)
+	}
+	return ""
+}
+
+// Evaluate returns the user's assignment in the experiment. A persisted assignment, whether assigned or forced, is
+// reused as is, otherwise the user is assigned a bucket at the current time.
+func (c *BaseSystemConfigExperiment) Evaluate(userID, experimentID string, assigned *Experiment, nowSec int64) *Experiment {
+	if assigned != nil && assigned.Bucket != "" {
+		return assigned
+	}
+	return &Experiment{
+		Id:            experimentID,
+		Bucket:        c.AssignBucket(userID, experimentID),
+		AssignTimeSec: nowSec,
+	}
+}
+
+// Force returns an assignment which overrides the user's bucket, or ErrExperimentBucketInvalid if the experiment has
+// no bucket with the name.
+func (c *BaseSystemConfigExperiment) Force(experimentID, bucket string, nowSec int64) (*Experiment, error) {
+	if c == nil || !slices.ContainsFunc(c.Buckets, func(b *BaseSystemConfigExperimentBucket) bool { return b != nil && b.Name == bucket }) {
+		return nil, ErrExperimentBucketInvalid
+	}
+	return &Experiment{
+		Id:            experimentID,
+		Bucket:        bucket,
+		AssignTimeSec: nowSec,
+		Forced:        true,
+	}, nil
+}
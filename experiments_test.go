@@ -0,0 +1,70 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBaseSystemConfigExperimentAssignBucket(t *testing.T) {
+	experiment := &BaseSystemConfigExperiment{Buckets: []*BaseSystemConfigExperimentBucket{
+		{Name: "control", Weight: 50},
+		{Name: "variant", Weight: 50},
+		{Name: "disabled", Weight: 0},
+	}}
+
+	seen := make(map[string]bool)
+	for _, userID := range []string{"user-1", "user-2", "user-3", "user-4", "user-5", "user-6", "user-7", "user-8"} {
+		bucket := experiment.AssignBucket(userID, "onboarding")
+		for i := 0; i < 10; i++ {
+			if repeated := experiment.AssignBucket(userID, "onboarding"); repeated != bucket {
+				t.Fatalf("user %s assigned %q then %q", userID, bucket, repeated)
+			}
+		}
+		if bucket == "disabled" {
+			t.Fatalf("user %s assigned a bucket without weight", userID)
+		}
+		seen[bucket] = true
+	}
+	if !seen["control"] || !seen["variant"] {
+		t.Errorf("buckets assigned = %v, want both control and variant", seen)
+	}
+
+	assigned := experiment.Evaluate("user-1", "onboarding", nil, 1000)
+	if assigned.Bucket != experiment.AssignBucket("user-1", "onboarding") || assigned.Forced {
+		t.Fatalf("first evaluation = %+v, want the assigned bucket", assigned)
+	}
+	if again := experiment.Evaluate("user-1", "onboarding", assigned, 2000); again != assigned {
+		t.Fatalf("second evaluation = %+v, want the persisted assignment", again)
+	}
+
+	// A forced bucket wins over the assignment.
+	override := "control"
+	if assigned.Bucket == "control" {
+		override = "variant"
+	}
+	forced, err := experiment.Force("onboarding", override, 3000)
+	if err != nil {
+		t.Fatalf("force: %v", err)
+	}
+	if evaluated := experiment.Evaluate("user-1", "onboarding", forced, 4000); evaluated.Bucket != override || !evaluated.Forced {
+		t.Fatalf("evaluation after override = %+v, want forced %q", evaluated, override)
+	}
+
+	if _, err = experiment.Force("onboarding", "missing", 3000); !errors.Is(err, ErrExperimentBucketInvalid) {
+		t.Fatalf("force an unknown bucket returned %v, want %v", err, ErrExperimentBucketInvalid)
+	}
+}
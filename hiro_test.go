@@ -0,0 +1,168 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/heroiclabs/nakama-common/api"
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// testLogger discards all log messages.
+type testLogger struct{}
+
+func (l *testLogger) Debug(string, ...interface{})                     {}
+func (l *testLogger) Info(string, ...interface{})                      {}
+func (l *testLogger) Warn(string, ...interface{})                      {}
+func (l *testLogger) Error(string, ...interface{})                     {}
+func (l *testLogger) WithField(string, interface{}) runtime.Logger     { return l }
+func (l *testLogger) WithFields(map[string]interface{}) runtime.Logger { return l }
+func (l *testLogger) Fields() map[string]interface{}                   { return nil }
+
+// testNakamaModule is a NakamaModule which only provides Satori and in-memory storage, calls to any other function
+// panic.
+type testNakamaModule struct {
+	runtime.NakamaModule
+	satori *testSatori
+
+	mu       sync.Mutex
+	storage  map[string]*api.StorageObject
+	versions int
+}
+
+func (nk *testNakamaModule) GetSatori() runtime.Satori {
+	return nk.satori
+}
+
+// testSatori returns fixed flags and live events, and counts the requests made for them.
+type testSatori struct {
+	runtime.Satori
+
+	mu              sync.Mutex
+	flags           []*runtime.Flag
+	liveEvents      []*runtime.LiveEvent
+	flagsCalls      int
+	liveEventsCalls int
+}
+
+func newTestNakamaModule(flags []*runtime.Flag, liveEvents []*runtime.LiveEvent) *testNakamaModule {
+	return &testNakamaModule{satori: &testSatori{flags: flags, liveEvents: liveEvents}, storage: make(map[string]*api.StorageObject)}
+}
+
+func (nk *testNakamaModule) StorageRead(_ context.Context, reads []*runtime.StorageRead) ([]*api.StorageObject, error) {
+	nk.mu.Lock()
+	defer nk.mu.Unlock()
+	objects := make([]*api.StorageObject, 0, len(reads))
+	for _, read := range reads {
+		if object, found := nk.storage[read.Collection+"/"+read.Key+"/"+read.UserID]; found {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// StorageWrite writes all objects or none, checking versions like Nakama: "*" only creates an object, any other
+// non-empty version must match the stored object.
+func (nk *testNakamaModule) StorageWrite(_ context.Context, writes []*runtime.StorageWrite) ([]*api.StorageObjectAck, error) {
+	nk.mu.Lock()
+	defer nk.mu.Unlock()
+	for _, write := range writes {
+		object, found := nk.storage[write.Collection+"/"+write.Key+"/"+write.UserID]
+		if (write.Version == "*" && found) || (write.Version != "" && write.Version != "*" && (!found || object.Version != write.Version)) {
+			return nil, runtime.NewError("storage write rejected - version check failed", 3)
+		}
+	}
+	acks := make([]*api.StorageObjectAck, 0, len(writes))
+	for _, write := range writes {
+		nk.versions++
+		version := strconv.Itoa(nk.versions)
+		nk.storage[write.Collection+"/"+write.Key+"/"+write.UserID] = &api.StorageObject{
+			Collection: write.Collection,
+			Key:        write.Key,
+			UserId:     write.UserID,
+			Value:      write.Value,
+			Version:    version,
+		}
+		acks = append(acks, &api.StorageObjectAck{Collection: write.Collection, Key: write.Key, UserId: write.UserID, Version: version})
+	}
+	return acks, nil
+}
+
+func (s *testSatori) FlagsList(_ context.Context, _ string, names ...string) (*runtime.FlagList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flagsCalls++
+	flagList := &runtime.FlagList{}
+	for _, flag := range s.flags {
+		for _, name := range names {
+			if flag.Name == name {
+				flagList.Flags = append(flagList.Flags, &runtime.Flag{Name: flag.Name, Value: flag.Value})
+				break
+			}
+		}
+	}
+	return flagList, nil
+}
+
+func (s *testSatori) LiveEventsList(context.Context, string, ...string) (*runtime.LiveEventList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.liveEventsCalls++
+	return &runtime.LiveEventList{LiveEvents: s.liveEvents}, nil
+}
+
+func (s *testSatori) setFlags(flags []*runtime.Flag) {
+	s.mu.Lock()
+	s.flags = flags
+	s.mu.Unlock()
+}
+
+func (s *testSatori) setLiveEvents(liveEvents []*runtime.LiveEvent) {
+	s.mu.Lock()
+	s.liveEvents = liveEvents
+	s.mu.Unlock()
+}
+
+func (s *testSatori) calls() (flags, liveEvents int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flagsCalls, s.liveEventsCalls
+}
+
+// testRequestContext returns a new context for each request, as the Satori personalizer caches values per context.
+func testRequestContext(tb testing.TB) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	tb.Cleanup(cancel)
+	return ctx
+}
+
+// testSystem is a System whose config is built fresh by a function on each call, as the gameplay systems return a
+// copy of their base config.
+type testSystem struct {
+	systemType SystemType
+	config     func() any
+}
+
+func (s *testSystem) GetType() SystemType {
+	return s.systemType
+}
+
+func (s *testSystem) GetConfig() any {
+	return s.config()
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// IdempotencyCollectionDefault is the storage collection used by the default StorageIdempotencyStore.
+const IdempotencyCollectionDefault = "hiro_idempotency"
+
+// The IdempotencyStore holds the markers which make grants and replay protected requests apply only once, such as a
+// grant-once marker keyed by an idempotency key. Markers expire after their TTL so the store does not grow forever.
+//
+// IdempotencyStore implementations must safely handle concurrent calls.
+type IdempotencyStore interface {
+	// SetMarker sets a marker for the user if one is not already set and unexpired. It returns false if the marker was
+	// already set, in which case the operation it guards must not be applied again. A zero TTL never expires.
+	SetMarker(ctx context.Context, userID, key string, ttl time.Duration) (set bool, err error)
+
+	// HasMarker returns true if an unexpired marker is set for the user.
+	HasMarker(ctx context.Context, userID, key string) (found bool, err error)
+}
+
+var (
+	_ IdempotencyStore = (*LocalIdempotencyStore)(nil)
+	_ IdempotencyStore = (*StorageIdempotencyStore)(nil)
+)
+
+// LocalIdempotencyStore is an in-memory IdempotencyStore. Markers are lost on restart and are not shared between game
+// servers, so it's intended for tests.
+type LocalIdempotencyStore struct {
+	mu      sync.Mutex
+	markers map[string]time.Time
+}
+
+// NewLocalIdempotencyStore creates an in-memory IdempotencyStore.
+func NewLocalIdempotencyStore() *LocalIdempotencyStore {
+	return &LocalIdempotencyStore{
+		markers: make(map[string]time.Time),
+	}
+}
+
+func (s *LocalIdempotencyStore) SetMarker(_ context.Context, userID, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if expiry, found := s.markers[userID+"/"+key]; found && (expiry.IsZero() || now.Before(expiry)) {
+		return false, nil
+	}
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = now.Add(ttl)
+	}
+	s.markers[userID+"/"+key] = expiry
+	return true, nil
+}
+
+func (s *LocalIdempotencyStore) HasMarker(_ context.Context, userID, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, found := s.markers[userID+"/"+key]
+	return found && (expiry.IsZero() || time.Now().Before(expiry)), nil
+}
+
+// StorageIdempotencyStore is an IdempotencyStore which keeps markers in Nakama storage, so they survive restarts and
+// are shared between game servers. It's the default store.
+type StorageIdempotencyStore struct {
+	nk         runtime.NakamaModule
+	collection string
+}
+
+type storageIdempotencyMarker struct {
+	CreateTimeSec int64 `json:"create_time_sec,omitempty"`
+	ExpiryTimeSec int64 `json:"expiry_time_sec,omitempty"`
+}
+
+// NewStorageIdempotencyStore creates an IdempotencyStore in the given storage collection, which defaults to
+// IdempotencyCollectionDefault.
+func NewStorageIdempotencyStore(nk runtime.NakamaModule, collection string) *StorageIdempotencyStore {
+	if collection == "" {
+		collection = IdempotencyCollectionDefault
+	}
+	return &StorageIdempotencyStore{
+		nk:         nk,
+		collection: collection,
+	}
+}
+
+func (s *StorageIdempotencyStore) SetMarker(ctx context.Context, userID, key string, ttl time.Duration) (bool, error) {
+	found, version, err := s.read(ctx, userID, key)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		return false, nil
+	}
+	if version == "" {
+		// Only create the marker if no other request has created it since the read.
+		version = "*"
+	}
+
+	now := time.Now()
+	marker := &storageIdempotencyMarker{CreateTimeSec: now.Unix()}
+	if ttl > 0 {
+		marker.ExpiryTimeSec = now.Add(ttl).Unix()
+	}
+	value, err := json.Marshal(marker)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = s.nk.StorageWrite(ctx, []*runtime.StorageWrite{{
+		Collection:      s.collection,
+		Key:             key,
+		UserID:          userID,
+		Value:           string(value),
+		Version:         version,
+		PermissionRead:  0,
+		PermissionWrite: 0,
+	}}); err != nil {
+		// The version check fails if another request set the marker concurrently.
+		if found, _, readErr := s.read(ctx, userID, key); readErr == nil && found {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *StorageIdempotencyStore) HasMarker(ctx context.Context, userID, key string) (bool, error) {
+	found, _, err := s.read(ctx, userID, key)
+	return found, err
+}
+
+// read returns true if an unexpired marker is set, and the version of the stored marker if one exists at all.
+func (s *StorageIdempotencyStore) read(ctx context.Context, userID, key string) (bool, string, error) {
+	objects, err := s.nk.StorageRead(ctx, []*runtime.StorageRead{{
+		Collection: s.collection,
+		Key:        key,
+		UserID:     userID,
+	}})
+	if err != nil {
+		return false, "", err
+	}
+	if len(objects) == 0 {
+		return false, "", nil
+	}
+
+	marker := &storageIdempotencyMarker{}
+	if err = json.Unmarshal([]byte(objects[0].Value), marker); err != nil {
+		return false, "", err
+	}
+	expired := marker.ExpiryTimeSec > 0 && time.Now().Unix() >= marker.ExpiryTimeSec
+	return !expired, objects[0].Version, nil
+}
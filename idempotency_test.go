@@ -0,0 +1,66 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStorageIdempotencyStorePersists(t *testing.T) {
+	ctx := testRequestContext(t)
+	nk := newTestNakamaModule(nil, nil)
+
+	store := NewStorageIdempotencyStore(nk, "")
+	if set, err := store.SetMarker(ctx, "user", "purchase-1", time.Hour); err != nil || !set {
+		t.Fatalf("first set marker = %v, %v, want true", set, err)
+	}
+
+	// A new store on the same storage, as after a restart, sees the marker.
+	restarted := NewStorageIdempotencyStore(nk, "")
+	if found, err := restarted.HasMarker(ctx, "user", "purchase-1"); err != nil || !found {
+		t.Fatalf("has marker after restart = %v, %v, want true", found, err)
+	}
+	if set, err := restarted.SetMarker(ctx, "user", "purchase-1", time.Hour); err != nil || set {
+		t.Fatalf("second set marker = %v, %v, want false", set, err)
+	}
+
+	if found, err := restarted.HasMarker(ctx, "other", "purchase-1"); err != nil || found {
+		t.Fatalf("has marker for another user = %v, %v, want false", found, err)
+	}
+}
+
+func TestLocalIdempotencyStoreExpiry(t *testing.T) {
+	ctx := testRequestContext(t)
+	store := NewLocalIdempotencyStore()
+
+	if set, _ := store.SetMarker(ctx, "user", "grant", 20*time.Millisecond); !set {
+		t.Fatal("first set marker = false, want true")
+	}
+	if set, _ := store.SetMarker(ctx, "user", "grant", 20*time.Millisecond); set {
+		t.Fatal("set marker before expiry = true, want false")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if found, _ := store.HasMarker(ctx, "user", "grant"); found {
+		t.Fatal("has marker after expiry = true, want false")
+	}
+	if set, _ := store.SetMarker(ctx, "user", "grant", 0); !set {
+		t.Fatal("set marker after expiry = false, want true")
+	}
+	if found, _ := store.HasMarker(ctx, "user", "grant"); !found {
+		t.Fatal("has marker without a TTL = false, want true")
+	}
+}
@@ -20,8 +20,103 @@ import (
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+var ErrIncentiveClaimDenied = runtime.NewError("incentive claim denied", 7) // PERMISSION_DENIED
+
 type IncentivesConfig struct {
 	Incentives map[string]*IncentivesConfigIncentive `json:"incentives,omitempty"`
+	Velocity   *IncentivesConfigVelocity             `json:"velocity,omitempty"`
+}
+
+// IncentivesConfigVelocity configures the built-in checks on the rate of incentive claims made by each user and
+// device over a sliding window.
+type IncentivesConfigVelocity struct {
+	WindowSec          int64 `json:"window_sec,omitempty"`
+	MaxClaimsPerUser   int   `json:"max_claims_per_user,omitempty"`
+	MaxClaimsPerDevice int   `json:"max_claims_per_device,omitempty"`
+	// The decision made for claims which exceed a limit, defaults to flag.
+	Action string `json:"action,omitempty" hirovalidate:"oneof=allow|flag|shadow_deny|deny,policy=default"`
+}
+
+// The decisions an OnIncentiveClaim function can make about an incentive claim.
+const (
+	IncentiveClaimAllow = "allow"
+	// IncentiveClaimFlag accepts the claim and grants its reward, and records it for review.
+	IncentiveClaimFlag = "flag"
+	// IncentiveClaimShadowDeny appears to accept the claim but grants nothing, and records it for review.
+	IncentiveClaimShadowDeny = "shadow_deny"
+	// IncentiveClaimDeny rejects the claim with ErrIncentiveClaimDenied.
+	IncentiveClaimDeny = "deny"
+)
+
+// IncentiveClaimMetadataDevice is the claim metadata key which holds the client's device ID, used by the per-device
+// velocity check.
+const IncentiveClaimMetadataDevice = "device_id"
+
+type incentiveClaimMetadataContextKey struct{}
+
+// WithIncentiveClaimMetadata returns a context which passes client metadata, such as the device ID, to the claim
+// checks of any incentive claim performed with it.
+func WithIncentiveClaimMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	return context.WithValue(ctx, incentiveClaimMetadataContextKey{}, metadata)
+}
+
+// IncentiveClaimMetadataFromContext returns the client metadata set on the context with WithIncentiveClaimMetadata.
+func IncentiveClaimMetadataFromContext(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(incentiveClaimMetadataContextKey{}).(map[string]string)
+	return metadata
+}
+
+// IncentiveClaimContext describes an incentive claim and the recent claims made by the same user and device.
+type IncentiveClaimContext struct {
+	UserId      string
+	Code        string
+	IncentiveId string
+	Config      *IncentivesConfig
+	// Client metadata passed with WithIncentiveClaimMetadata.
+	Metadata map[string]string
+	// The number of claims made within the velocity window, not including this one.
+	UserClaims   int
+	DeviceClaims int
+}
+
+// IncentiveFlaggedClaim is an incentive claim which was flagged or shadow denied for review.
+type IncentiveFlaggedClaim struct {
+	UserId      string            `json:"user_id,omitempty"`
+	Code        string            `json:"code,omitempty"`
+	IncentiveId string            `json:"incentive_id,omitempty"`
+	Decision    string            `json:"decision,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	TimeSec     int64             `json:"time_sec,omitempty"`
+}
+
+// OnIncentiveClaim analyses an incentive claim and returns whether to allow, flag, shadow deny, or deny it, along with
+// the reason recorded on flagged claims.
+type OnIncentiveClaim func(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, claim *IncentiveClaimContext) (decision, reason string, err error)
+
+var _ OnIncentiveClaim = IncentiveVelocityCheck
+
+// IncentiveVelocityCheck is the built-in OnIncentiveClaim function, which checks each claim against the velocity
+// configuration.
+func IncentiveVelocityCheck(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, claim *IncentiveClaimContext) (string, string, error) {
+	if claim == nil || claim.Config == nil || claim.Config.Velocity == nil {
+		return IncentiveClaimAllow, "", nil
+	}
+	velocity := claim.Config.Velocity
+
+	decision := velocity.Action
+	if decision == "" {
+		decision = IncentiveClaimFlag
+	}
+
+	if velocity.MaxClaimsPerUser > 0 && claim.UserClaims >= velocity.MaxClaimsPerUser {
+		return decision, "max_claims_per_user", nil
+	}
+	if velocity.MaxClaimsPerDevice > 0 && claim.Metadata[IncentiveClaimMetadataDevice] != "" && claim.DeviceClaims >= velocity.MaxClaimsPerDevice {
+		return decision, "max_claims_per_device", nil
+	}
+
+	return IncentiveClaimAllow, "", nil
 }
 
 type IncentivesConfigIncentive struct {
@@ -53,6 +148,14 @@ type IncentivesSystem interface {
 
 	RecipientClaim(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, code string) (incentive *IncentiveInfo, err error)
 
+	// SetOnIncentiveClaim sets a custom function which analyses each recipient claim and can flag or deny it. When no
+	// function is set the built-in velocity check is used if velocity is configured.
+	SetOnIncentiveClaim(fn OnIncentiveClaim)
+
+	// ListFlaggedClaims returns the claims which were flagged or shadow denied for review, newest first. It's intended
+	// for server-to-server use.
+	ListFlaggedClaims(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, limit int, cursor string) (claims []*IncentiveFlaggedClaim, nextCursor string, err error)
+
 	// SetOnSenderReward sets a custom reward function which will run after an incentive sender's reward is rolled.
 	SetOnSenderReward(fn OnReward[*IncentivesConfigIncentive])
 
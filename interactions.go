@@ -0,0 +1,64 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import "strconv"
+
+// The reserved additional properties set on the achievements, incentives, streaks, unlockables, and other claimable
+// objects returned in status and list responses. Values are Unix times in seconds, and unset if the user has never
+// interacted with the object.
+const (
+	// PropertyLastClaimTimeSec is the last time the user claimed a reward from the object.
+	PropertyLastClaimTimeSec = "hiro_last_claim_time_sec"
+	// PropertyLastInteractionTimeSec is the last time the user made progress on, claimed, or otherwise changed the
+	// object.
+	PropertyLastInteractionTimeSec = "hiro_last_interaction_time_sec"
+)
+
+// LastInteraction is when a user last claimed from, and last interacted with, an object in a gameplay system. It's
+// tracked uniformly across the claimable systems so projects do not each record it themselves.
+type LastInteraction struct {
+	System SystemType `json:"system,omitempty"`
+	// The ID of the object, such as an achievement or streak ID.
+	Id                 string `json:"id,omitempty"`
+	ClaimTimeSec       int64  `json:"claim_time_sec,omitempty"`
+	InteractionTimeSec int64  `json:"interaction_time_sec,omitempty"`
+}
+
+// Record updates the interaction time, and the claim time if the interaction was a claim.
+func (i *LastInteraction) Record(nowSec int64, claim bool) {
+	i.InteractionTimeSec = max(i.InteractionTimeSec, nowSec)
+	if claim {
+		i.ClaimTimeSec = max(i.ClaimTimeSec, nowSec)
+	}
+}
+
+// SetProperties sets the reserved last interaction properties on the additional properties of a response object, and
+// returns them. A nil map is created if there's anything to set.
+func (i *LastInteraction) SetProperties(properties map[string]string) map[string]string {
+	if i == nil || (i.ClaimTimeSec == 0 && i.InteractionTimeSec == 0) {
+		return properties
+	}
+	if properties == nil {
+		properties = make(map[string]string, 2)
+	}
+	if i.ClaimTimeSec > 0 {
+		properties[PropertyLastClaimTimeSec] = strconv.FormatInt(i.ClaimTimeSec, 10)
+	}
+	if i.InteractionTimeSec > 0 {
+		properties[PropertyLastInteractionTimeSec] = strconv.FormatInt(i.InteractionTimeSec, 10)
+	}
+	return properties
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import "testing"
+
+func TestLastInteractionRecord(t *testing.T) {
+	interaction := &LastInteraction{System: SystemTypeAchievements, Id: "first_win"}
+
+	interaction.Record(1000, false)
+	if interaction.InteractionTimeSec != 1000 || interaction.ClaimTimeSec != 0 {
+		t.Fatalf("after progress interaction = %d, claim = %d, want 1000 and 0", interaction.InteractionTimeSec, interaction.ClaimTimeSec)
+	}
+
+	interaction.Record(2000, true)
+	if interaction.InteractionTimeSec != 2000 || interaction.ClaimTimeSec != 2000 {
+		t.Fatalf("after claim interaction = %d, claim = %d, want 2000 and 2000", interaction.InteractionTimeSec, interaction.ClaimTimeSec)
+	}
+
+	interaction.Record(3000, false)
+	if interaction.InteractionTimeSec != 3000 || interaction.ClaimTimeSec != 2000 {
+		t.Fatalf("after progress interaction = %d, claim = %d, want 3000 and 2000", interaction.InteractionTimeSec, interaction.ClaimTimeSec)
+	}
+
+	// An out of order earlier interaction does not move the times back.
+	interaction.Record(1500, true)
+	if interaction.InteractionTimeSec != 3000 || interaction.ClaimTimeSec != 2000 {
+		t.Fatalf("after earlier claim interaction = %d, claim = %d, want 3000 and 2000", interaction.InteractionTimeSec, interaction.ClaimTimeSec)
+	}
+
+	properties := interaction.SetProperties(nil)
+	if properties[PropertyLastClaimTimeSec] != "2000" || properties[PropertyLastInteractionTimeSec] != "3000" {
+		t.Fatalf("properties = %v, want claim 2000 and interaction 3000", properties)
+	}
+}
@@ -16,14 +16,111 @@ package hiro
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+// The reserved string properties used to persist player flags on item instances. When stacks are merged the result
+// is locked or favorite if any of the merged stacks were, and when a stack is split each part keeps the flags.
+const (
+	InventoryItemPropertyLocked   = "hiro_locked"
+	InventoryItemPropertyFavorite = "hiro_favorite"
+	// InventoryItemPropertyBound is set on item instances which have become bound to the user and can't be transferred.
+	InventoryItemPropertyBound = "hiro_bound"
+	// InventoryItemPropertyRevision is the revision of the inventory at which the item instance was last mutated.
+	InventoryItemPropertyRevision = "hiro_revision"
+)
+
+// InventoryItemFlags are set by a player on an item instance. A nil value leaves the flag unchanged.
+type InventoryItemFlags struct {
+	// Locked items are protected from being consumed, sold, traded, or used in any bulk operation.
+	Locked *bool `json:"locked,omitempty"`
+	// Favorite items are highlighted to the player and are protected in the same way as locked items.
+	Favorite *bool `json:"favorite,omitempty"`
+}
+
+// InventoryItemBound returns true if the item instance is bound to the user and can't be transferred to another user,
+// such as by listing it in an auction.
+func InventoryItemBound(item *InventoryItem) bool {
+	if item == nil {
+		return false
+	}
+	return item.StringProperties[InventoryItemPropertyBound] == "true"
+}
+
+// InventoryItemLocked returns true if the item instance is protected by either the locked or favorite flags.
+func InventoryItemLocked(item *InventoryItem) bool {
+	if item == nil {
+		return false
+	}
+	return item.StringProperties[InventoryItemPropertyLocked] == "true" || item.StringProperties[InventoryItemPropertyFavorite] == "true"
+}
+
+// InventoryContainerMain is the ID of the default container which items are granted into.
+const InventoryContainerMain = "main"
+
+type inventoryContainerContextKey struct{}
+
+// WithTargetContainer returns a context which grants the items of any claim or grant performed with it into the given
+// inventory container instead of the main container.
+func WithTargetContainer(ctx context.Context, containerID string) context.Context {
+	return context.WithValue(ctx, inventoryContainerContextKey{}, containerID)
+}
+
+// TargetContainerFromContext returns the inventory container set on the context with WithTargetContainer, otherwise
+// the main container.
+func TargetContainerFromContext(ctx context.Context) string {
+	if containerID, ok := ctx.Value(inventoryContainerContextKey{}).(string); ok && containerID != "" {
+		return containerID
+	}
+	return InventoryContainerMain
+}
+
+type inventoryGrantAllOrNothingContextKey struct{}
+
+// WithGrantAllOrNothing returns a context which makes any grant of several items performed with it fail without
+// granting anything if one of the items cannot be fully granted, instead of granting what fits.
+func WithGrantAllOrNothing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, inventoryGrantAllOrNothingContextKey{}, true)
+}
+
+// GrantAllOrNothingFromContext returns true if the context was created with WithGrantAllOrNothing.
+func GrantAllOrNothingFromContext(ctx context.Context) bool {
+	allOrNothing, _ := ctx.Value(inventoryGrantAllOrNothingContextKey{}).(bool)
+	return allOrNothing
+}
+
+// InventoryGrantError is returned by an all-or-nothing grant when an item cannot be fully granted, for example due to
+// its max count or inventory limits. Nothing is granted. It wraps ErrItemsGrantBlocked.
+type InventoryGrantError struct {
+	// The item which blocked the grant.
+	ItemID string
+	// The amount of the item requested and the amount which could have been granted.
+	Requested int64
+	Grantable int64
+}
+
+func (e *InventoryGrantError) Error() string {
+	return ErrItemsGrantBlocked.Error() + " by " + e.ItemID
+}
+
+func (e *InventoryGrantError) Unwrap() error {
+	return ErrItemsGrantBlocked
+}
+
 type InventoryConfig struct {
-	Items    map[string]*InventoryConfigItem `json:"items,omitempty"`
-	Limits   *InventoryConfigLimits          `json:"limits,omitempty"`
-	ItemSets map[string]map[string]bool      `json:"-"` // Auto-computed when the config is read or personalized.
+	Items      map[string]*InventoryConfigItem      `json:"items,omitempty"`
+	Limits     *InventoryConfigLimits               `json:"limits,omitempty"`
+	Containers map[string]*InventoryConfigContainer `json:"containers,omitempty"`
+	// Optional signed ownership attestations for external services, such as a website showing a player's cosmetics.
+	Attestation *InventoryConfigAttestation `json:"attestation,omitempty"`
+	ItemSets    map[string]map[string]bool  `json:"-"` // Auto-computed when the config is read or personalized.
 }
 
 type InventoryConfigItem struct {
@@ -39,6 +136,8 @@ type InventoryConfigItem struct {
 	NumericProperties map[string]float64   `json:"numeric_properties,omitempty"`
 	Disabled          bool                 `json:"disabled,omitempty"`
 	KeepZero          bool                 `json:"keep_zero,omitempty"`
+	BindOnPickup      bool                 `json:"bind_on_pickup,omitempty"`
+	BindOnUse         bool                 `json:"bind_on_use,omitempty"`
 }
 
 type InventoryConfigLimits struct {
@@ -46,6 +145,90 @@ type InventoryConfigLimits struct {
 	ItemSets   map[string]int64 `json:"item_sets,omitempty"`
 }
 
+// InventoryConfigContainer is a separate storage area of the inventory, such as a vault.
+type InventoryConfigContainer struct {
+	Name string `json:"name,omitempty"`
+	// The maximum number of item instances the container holds, zero is unlimited. Grants which would exceed it fail
+	// with ErrContainerFull.
+	Capacity int64 `json:"capacity,omitempty"`
+}
+
+// InventoryConfigAttestation configures the signed ownership attestations minted for external services.
+type InventoryConfigAttestation struct {
+	// The key used to sign attestations with HMAC-SHA256, shared with the services which verify them.
+	SigningKey string `json:"signing_key,omitempty"`
+	// How long an attestation is valid after it's issued, defaults to 300 seconds.
+	ExpirySec int64 `json:"expiry_sec,omitempty"`
+	// If set only items in these categories may appear in attestations.
+	Categories []string `json:"categories,omitempty"`
+}
+
+// InventoryAttestation is a signed statement that a user owned counts of items when it was issued.
+type InventoryAttestation struct {
+	UserId        string           `json:"user_id,omitempty"`
+	Items         map[string]int64 `json:"items,omitempty"`
+	IssuedAtSec   int64            `json:"issued_at_sec,omitempty"`
+	ExpiryTimeSec int64            `json:"expiry_time_sec,omitempty"`
+}
+
+// InventoryAttestationSign returns a token for the attestation, made of its base64 encoded JSON and hex encoded
+// HMAC-SHA256 signature separated by a dot.
+func InventoryAttestationSign(key string, attestation *InventoryAttestation) (string, error) {
+	payload, err := json.Marshal(attestation)
+	if err != nil {
+		return "", ErrPayloadEncode
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(encoded))
+	return encoded + "." + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// InventoryAttestationVerify checks the signature of an attestation token in constant time and returns the
+// attestation, or ErrAttestationExpired if it has passed its expiry at the given time.
+func InventoryAttestationVerify(key, token string, nowSec int64) (*InventoryAttestation, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, ErrAttestationInvalid
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, ErrAttestationInvalid
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(encoded))
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return nil, ErrAttestationInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrAttestationInvalid
+	}
+	attestation := &InventoryAttestation{}
+	if err := json.Unmarshal(payload, attestation); err != nil {
+		return nil, ErrAttestationInvalid
+	}
+	if attestation.ExpiryTimeSec <= nowSec {
+		return nil, ErrAttestationExpired
+	}
+	return attestation, nil
+}
+
+// InventoryDelta is the change to a user's inventory since a sync token was issued.
+type InventoryDelta struct {
+	// Item instances added or changed since the token, keyed by instance ID. When FullResync is set this is the whole
+	// inventory.
+	Items map[string]*InventoryItem `json:"items,omitempty"`
+	// Instance IDs removed since the token.
+	RemovedInstanceIds []string `json:"removed_instance_ids,omitempty"`
+	// The opaque token to pass to the next call.
+	SyncToken string `json:"sync_token,omitempty"`
+	// True if the token was empty, expired, or issued before a storage layout migration, so the client must replace
+	// its local inventory with Items rather than apply the delta.
+	FullResync bool `json:"full_resync,omitempty"`
+}
+
 // The InventorySystem provides a gameplay system which can manage a player's inventory.
 //
 // A player can have items added via economy rewards, or directly.
@@ -58,15 +241,43 @@ type InventorySystem interface {
 	// ListInventoryItems will return the items which are part of a user's inventory by ID.
 	ListInventoryItems(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, category string) (inventory *Inventory, err error)
 
+	// ListInventoryItemsDelta returns the item instances added, changed, or removed since the sync token was issued,
+	// so clients with large inventories do not download them in full each session. An empty, expired, or outdated token
+	// returns the full inventory with FullResync set.
+	ListInventoryItemsDelta(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, category, syncToken string) (delta *InventoryDelta, err error)
+
 	// ConsumeItems will deduct the item(s) from the user's inventory and run the consume reward for each one, if defined.
+	// Locked item instances are skipped when consuming by item ID, and fail with ErrItemsLocked when given by instance ID.
 	ConsumeItems(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, itemIDs, instanceIDs map[string]int64, overConsume bool) (updatedInventory *Inventory, rewards map[string][]*Reward, instanceRewards map[string][]*Reward, err error)
 
-	// GrantItems will add the item(s) to a user's inventory by ID.
+	// GrantItems will add the item(s) to a user's inventory by ID. Amounts which do not fit are returned as not granted,
+	// unless the context was created with WithGrantAllOrNothing, in which case the whole grant fails with an
+	// *InventoryGrantError for the first blocking item and nothing is written.
 	GrantItems(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, itemIDs map[string]int64, ignoreLimits bool) (updatedInventory *Inventory, newItems map[string]*InventoryItem, updatedItems map[string]*InventoryItem, notGrantedItemIDs map[string]int64, err error)
 
 	// UpdateItems will update the properties which are stored on each item by instance ID for a user.
 	UpdateItems(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, instanceIDs map[string]*InventoryUpdateItemProperties) (updatedInventory *Inventory, err error)
 
+	// ImportItemDefinitions adds or replaces live item definitions which extend the static item catalog without a
+	// config redeploy. Definitions are validated for unique IDs among the static items, known categories, and reward
+	// references before they're stored, and become grantable and purchasable immediately.
+	ImportItemDefinitions(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, items map[string]*InventoryConfigItem) (err error)
+
+	// ExportItemDefinitions returns the live item definitions which have been added at runtime.
+	ExportItemDefinitions(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule) (items map[string]*InventoryConfigItem, err error)
+
+	// DeleteItemDefinition removes a live item definition. It fails with ErrItemDefinitionOwned and the number of users
+	// who own an instance of the item if any user does.
+	DeleteItemDefinition(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, itemID string) (ownerCount int64, err error)
+
+	// Attest mints a signed, short-lived attestation of the counts the user owns of the given items, for
+	// server-to-server use by external services which cannot access storage. Items outside the configured categories
+	// fail with ErrAttestationCategory.
+	Attest(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, itemIDs []string) (token string, attestation *InventoryAttestation, err error)
+
+	// SetItemFlags will set the lock and favorite flags on one or more item instances for a user.
+	SetItemFlags(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, instanceIDs map[string]*InventoryItemFlags) (updatedInventory *Inventory, err error)
+
 	// SetOnConsumeReward sets a custom reward function which will run after an inventory items' consume reward is rolled.
 	SetOnConsumeReward(fn OnReward[*InventoryConfigItem])
 }
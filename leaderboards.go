@@ -34,12 +34,70 @@ type LeaderboardsConfigLeaderboard struct {
 	ResetSchedule string   `json:"reset_schedule,omitempty"`
 	Authoritative bool     `json:"authoritative,omitempty"`
 	Regions       []string `json:"regions,omitempty"`
+	// Optional percentile and band enrichment of the caller's record.
+	Percentiles *LeaderboardsConfigLeaderboardPercentiles `json:"percentiles,omitempty"`
+}
+
+// LeaderboardsConfigLeaderboardPercentiles computes the caller's percentile from a cached count of the records on the
+// leaderboard, refreshed on an interval and shared between game servers, so no count is made per request.
+type LeaderboardsConfigLeaderboardPercentiles struct {
+	// How often the record count is refreshed, defaults to 60 seconds.
+	RefreshIntervalSec int64 `json:"refresh_interval_sec,omitempty"`
+	// Bands in ascending order of percentile, such as Gold for the top 5%.
+	Bands []*LeaderboardsConfigLeaderboardBand `json:"bands,omitempty"`
+}
+
+// LeaderboardsConfigLeaderboardBand labels records at or within a top percentile.
+type LeaderboardsConfigLeaderboardBand struct {
+	Name          string  `json:"name,omitempty"`
+	PercentileMax float64 `json:"percentile_max,omitempty"`
+}
+
+// LeaderboardPercentile is the position of a user's record relative to all records on a leaderboard.
+type LeaderboardPercentile struct {
+	Rank  int64 `json:"rank,omitempty"`
+	Total int64 `json:"total,omitempty"`
+	// The top percentile the record is within, for example 3 for the top 3%.
+	Percentile float64 `json:"percentile,omitempty"`
+	Band       string  `json:"band,omitempty"`
+}
+
+// Percentile computes the top percentile of a rank among the total records, and the first band it falls within. The
+// percentile is clamped to 100 when the cached total is behind the rank.
+func (c *LeaderboardsConfigLeaderboardPercentiles) Percentile(rank, total int64) *LeaderboardPercentile {
+	percentile := &LeaderboardPercentile{
+		Rank:  rank,
+		Total: total,
+	}
+	if rank <= 0 || total <= 0 {
+		return percentile
+	}
+
+	percentile.Percentile = min(float64(rank)/float64(total)*100, 100)
+	if c != nil {
+		for _, band := range c.Bands {
+			if band != nil && percentile.Percentile <= band.PercentileMax {
+				percentile.Band = band.Name
+				break
+			}
+		}
+	}
+	return percentile
 }
 
 // The LeaderboardsSystem defines a collection of leaderboards which can be defined as global or regional with Nakama
 // server.
 type LeaderboardsSystem interface {
 	System
+
+	// GetPercentile returns the percentile and band of the user's record on a leaderboard with percentiles configured.
+	// The percentile is computed from the cached record count.
+	GetPercentile(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, leaderboardID string) (percentile *LeaderboardPercentile, err error)
+
+	// PurgeUser removes the user's records from every configured leaderboard they appear in, such as when the user is
+	// banned. Set eventLeaderboards to also remove the user from the cohorts of every event leaderboard. It returns the
+	// number of records removed.
+	PurgeUser(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, eventLeaderboards bool) (removed int, err error)
 }
 
 // ValidateWriteScoreFn is a function used to validate the leaderboard score input.
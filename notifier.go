@@ -0,0 +1,89 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+// NotifierGrant describes a reward granted to a user, and where it was granted from.
+type NotifierGrant struct {
+	// The reward which was granted to the user.
+	Reward *Reward
+
+	// The Hiro system that granted the reward.
+	System System
+	// Source ID represents the identifier of the reward source, such as an achievement ID.
+	SourceId string
+	// Source represents the configuration of the reward source, such as an achievement config.
+	Source any
+}
+
+// The Notifier describes a service or similar target implementation that wishes to tell users about rewards which
+// have been granted to them, such as with an in-app notification or a push message.
+//
+// Notifiers are called asynchronously after a grant has completed on the central reward grant path, so they cannot
+// delay or fail the grant itself.
+//
+// Notifier implementations must safely handle concurrent calls.
+//
+// Implementations must handle any errors or retries internally, callers will not repeat calls in case
+// of errors.
+type Notifier interface {
+	// Notify is called once for each reward granted to a user.
+	Notify(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, grant *NotifierGrant)
+}
+
+var _ Notifier = (*NakamaNotifier)(nil)
+
+// NakamaNotifier sends a Nakama in-app notification to the user for each reward granted to them.
+type NakamaNotifier struct {
+	code       int
+	subject    string
+	persistent bool
+}
+
+// NewNakamaNotifier creates a Notifier which sends each grant as a Nakama notification with the code and subject. If
+// persistent is true the notification is stored so the user receives it when they're next online.
+func NewNakamaNotifier(code int, subject string, persistent bool) *NakamaNotifier {
+	return &NakamaNotifier{
+		code:       code,
+		subject:    subject,
+		persistent: persistent,
+	}
+}
+
+func (n *NakamaNotifier) Notify(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, grant *NotifierGrant) {
+	if grant == nil || grant.Reward == nil {
+		return
+	}
+
+	content := map[string]interface{}{
+		"source_id":      grant.SourceId,
+		"items":          grant.Reward.Items,
+		"currencies":     grant.Reward.Currencies,
+		"energies":       grant.Reward.Energies,
+		"grant_time_sec": grant.Reward.GrantTimeSec,
+	}
+	if grant.System != nil {
+		content["system_type"] = grant.System.GetType()
+	}
+
+	if err := nk.NotificationSend(ctx, userID, n.subject, content, n.code, "", n.persistent); err != nil {
+		logger.WithField("userID", userID).WithField("error", err.Error()).Error("failed to send reward notification")
+	}
+}
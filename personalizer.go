@@ -16,14 +16,487 @@ package hiro
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+var ErrPersonalizerConfigRejected = runtime.NewError("personalized config rejected, invalid values", 3) // INVALID_ARGUMENT
+
 // The Personalizer describes an intermediate server or service which can be used to personalize the base data
 // definitions defined for the gameplay systems.
+//
+// Personalized values are applied over the base data definitions with PersonalizerDecode, so slice fields are merged
+// according to the strategy annotated with the PersonalizerMergeTag.
 type Personalizer interface {
 	// GetValue returns a config which has been modified for a gameplay system,
 	// or nil if the config is not being adjusted by this personalizer.
 	GetValue(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, system System, identity string) (config any, err error)
 }
+
+// PersonalizerMergeTag is the struct tag used to annotate slice fields in system configs with the strategy used when
+// a personalizer applies a value over the field. Without an annotation the slice in the personalized value replaces
+// the slice in the config. The supported strategies are:
+//
+//	`hiro:"merge=replace"`  the personalized slice replaces the config slice, this is the default.
+//	`hiro:"merge=append"`   the personalized slice is appended to the config slice.
+//	`hiro:"merge=key:id"`   elements are matched on the named JSON field, personalized elements replace the config
+//	                        elements with the same key and all others are appended.
+//
+// Merge strategies only apply to slices reached through struct fields of the config, slices within map values are
+// replaced together with the map value they belong to. A personalized value which omits an annotated slice leaves it
+// unchanged, and an explicit null clears it whatever the strategy, since an empty list appends or merges nothing.
+const PersonalizerMergeTag = "hiro"
+
+const (
+	personalizerMergeReplace   = "replace"
+	personalizerMergeAppend    = "append"
+	personalizerMergeKeyPrefix = "key:"
+)
+
+type personalizerMergeField struct {
+	field    reflect.Value
+	previous reflect.Value
+	// An empty slice set on the field before decoding, which is left in place only if the value omits the field.
+	unset    reflect.Value
+	strategy string
+}
+
+// PersonalizerValueEmpty returns true if a personalized value is empty or only whitespace, such as a Satori flag which
+// has been cleared but not deleted. Personalizers treat an empty value as no override rather than a decode error.
+func PersonalizerValueEmpty(value string) bool {
+	return strings.TrimSpace(value) == ""
+}
+
+// PersonalizerLiveEventPriorityKey is the reserved top-level key in a live event value which sets its priority. Live
+// events which change the same config field are applied in ascending order of priority so the highest priority wins.
+// The key is removed from the value before it's decoded.
+const PersonalizerLiveEventPriorityKey = "hiro_priority"
+
+// PersonalizerLiveEventPriority returns the priority set on a live event value with PersonalizerLiveEventPriorityKey,
+// and the value without the key. Values without a priority have priority 0 and are returned unchanged.
+func PersonalizerLiveEventPriority(value string) (priority int, remaining string) {
+	if !strings.Contains(value, `"`+PersonalizerLiveEventPriorityKey+`"`) {
+		return 0, value
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return 0, value
+	}
+	raw, found := fields[PersonalizerLiveEventPriorityKey]
+	if !found {
+		return 0, value
+	}
+	if err := json.Unmarshal(raw, &priority); err != nil {
+		return 0, value
+	}
+	delete(fields, PersonalizerLiveEventPriorityKey)
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return 0, value
+	}
+	return priority, string(stripped)
+}
+
+// PersonalizerDecode applies a personalized JSON value over a system config, honouring any merge strategies which
+// have been annotated on its slice fields with the PersonalizerMergeTag.
+func PersonalizerDecode(value string, config any) error {
+	fields := personalizerMergeFields(reflect.ValueOf(config), nil)
+	for _, f := range fields {
+		// Detach the slice so the decoder cannot write into its backing array or the elements it points to. The
+		// placeholder has spare capacity so it can be told apart from an empty list, which is decoded as a new slice.
+		f.previous = reflect.ValueOf(f.field.Interface())
+		f.unset = reflect.MakeSlice(f.field.Type(), 0, 1)
+		f.field.Set(f.unset)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(config)
+
+	for _, f := range fields {
+		switch {
+		case err != nil:
+			f.field.Set(f.previous)
+		case f.field.IsNil():
+			// An explicit null clears the slice.
+		case f.field.Len() == 0 && f.field.Pointer() == f.unset.Pointer():
+			// The value omits the field.
+			f.field.Set(f.previous)
+		default:
+			f.field.Set(personalizerMerge(f.strategy, f.previous, f.field))
+		}
+	}
+
+	return err
+}
+
+func personalizerMergeFields(v reflect.Value, fields []*personalizerMergeField) []*personalizerMergeField {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return fields
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fields
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if strategy, ok := strings.CutPrefix(sf.Tag.Get(PersonalizerMergeTag), "merge="); ok && fv.Kind() == reflect.Slice {
+			if strategy != personalizerMergeReplace {
+				fields = append(fields, &personalizerMergeField{field: fv, strategy: strategy})
+			}
+			continue
+		}
+		fields = personalizerMergeFields(fv, fields)
+	}
+
+	return fields
+}
+
+func personalizerMerge(strategy string, previous, personalized reflect.Value) reflect.Value {
+	if strategy == personalizerMergeAppend {
+		merged := reflect.MakeSlice(previous.Type(), 0, previous.Len()+personalized.Len())
+		return reflect.AppendSlice(reflect.AppendSlice(merged, previous), personalized)
+	}
+
+	key, ok := strings.CutPrefix(strategy, personalizerMergeKeyPrefix)
+	if !ok {
+		// Unknown strategies fall back to replace.
+		return personalized
+	}
+
+	merged := reflect.MakeSlice(previous.Type(), previous.Len(), previous.Len()+personalized.Len())
+	reflect.Copy(merged, previous)
+	for i := 0; i < personalized.Len(); i++ {
+		element := personalized.Index(i)
+		elementKey, found := personalizerMergeKey(element, key)
+		replaced := false
+		if found {
+			for j := 0; j < merged.Len(); j++ {
+				if existingKey, ok := personalizerMergeKey(merged.Index(j), key); ok && existingKey == elementKey {
+					merged.Index(j).Set(element)
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			merged = reflect.Append(merged, element)
+		}
+	}
+
+	return merged
+}
+
+func personalizerMergeKey(v reflect.Value, key string) (any, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == key && v.Field(i).Comparable() {
+			return v.Field(i).Interface(), true
+		}
+	}
+
+	return nil, false
+}
+
+// personalizerCopy returns a deep copy of a config, so a personalized config can be reused without changes made to one
+// copy being seen in the others.
+func personalizerCopy(config any) any {
+	v := reflect.ValueOf(config)
+	if !v.IsValid() {
+		return config
+	}
+	c := reflect.New(v.Type()).Elem()
+	personalizerCopyValue(c, v)
+	return c.Interface()
+}
+
+func personalizerCopyValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.New(src.Type().Elem()))
+		personalizerCopyValue(dst.Elem(), src.Elem())
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		c := reflect.New(src.Elem().Type()).Elem()
+		personalizerCopyValue(c, src.Elem())
+		dst.Set(c)
+	case reflect.Struct:
+		// Unexported fields are copied shallowly, config fields are exported so they can be decoded.
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				personalizerCopyValue(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeMapWithSize(src.Type(), src.Len()))
+		iter := src.MapRange()
+		for iter.Next() {
+			c := reflect.New(src.Type().Elem()).Elem()
+			personalizerCopyValue(c, iter.Value())
+			dst.SetMapIndex(iter.Key(), c)
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		reflect.Copy(dst, src)
+		switch src.Type().Elem().Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			for i := 0; i < src.Len(); i++ {
+				personalizerCopyValue(dst.Index(i), src.Index(i))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			personalizerCopyValue(dst.Index(i), src.Index(i))
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// PersonalizerValidateTag is the struct tag used to annotate enum and bounded fields in system configs, which are
+// checked with PersonalizerValidate once personalizations have been applied. The options are comma separated:
+//
+//	`hirovalidate:"oneof=a|b"`        a string field must be one of the listed values.
+//	`hirovalidate:"min=0,max=1"`      a numeric field must be within the bounds, either of which may be omitted.
+//	`hirovalidate:"...,policy=reject"` the personalization is rejected, so the base config is used. This is the default.
+//	`hirovalidate:"...,policy=default"` the field is set to the value of the default option, or its zero value.
+//	`hirovalidate:"...,policy=clamp"`  a numeric field is clamped to the nearest bound, other fields use the default.
+//	`hirovalidate:"...,default=a"`    the value used by the default policy.
+//
+// Zero values are treated as unset and are not checked, so optional fields keep their own defaults.
+const PersonalizerValidateTag = "hirovalidate"
+
+// The policies applied to fields which fail validation after personalization.
+const (
+	PersonalizerValidatePolicyReject  = "reject"
+	PersonalizerValidatePolicyDefault = "default"
+	PersonalizerValidatePolicyClamp   = "clamp"
+)
+
+// PersonalizerViolation is a field with an invalid value after personalization, and how it was resolved.
+type PersonalizerViolation struct {
+	// The JSON path of the field, such as "event_leaderboards.weekly.tie_policy".
+	Path   string
+	Value  any
+	Policy string
+	// The value the field was set to, unless the policy is reject.
+	Resolved any
+}
+
+type personalizerValidateOptions struct {
+	oneOf        []string
+	min, max     *float64
+	policy       string
+	defaultValue string
+}
+
+// PersonalizerValidate checks the fields of a personalized config annotated with the PersonalizerValidateTag. Fields
+// with the default or clamp policy are fixed in place. It returns every violation found, and whether any violation
+// requires the personalization to be rejected.
+func PersonalizerValidate(config any) (violations []*PersonalizerViolation, reject bool) {
+	violations = personalizerValidate(reflect.ValueOf(config), "", violations)
+	for _, violation := range violations {
+		if violation.Policy == PersonalizerValidatePolicyReject {
+			reject = true
+			break
+		}
+	}
+	return violations, reject
+}
+
+func personalizerValidate(v reflect.Value, path string, violations []*PersonalizerViolation) []*PersonalizerViolation {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return violations
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			fieldPath := path
+			if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+				fieldPath = personalizerValidatePath(path, name)
+			} else if !sf.Anonymous {
+				fieldPath = personalizerValidatePath(path, sf.Name)
+			}
+			if tag := sf.Tag.Get(PersonalizerValidateTag); tag != "" && v.Field(i).CanSet() {
+				if violation := personalizerValidateField(v.Field(i), fieldPath, personalizerValidateParse(tag)); violation != nil {
+					violations = append(violations, violation)
+				}
+				continue
+			}
+			violations = personalizerValidate(v.Field(i), fieldPath, violations)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			violations = personalizerValidate(v.Index(i), personalizerValidatePath(path, strconv.Itoa(i)), violations)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elemPath := personalizerValidatePath(path, fmt.Sprint(iter.Key().Interface()))
+			elem := iter.Value()
+			if elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface {
+				violations = personalizerValidate(elem, elemPath, violations)
+				continue
+			}
+			// Map values are not addressable, so fix a copy and write it back.
+			copied := reflect.New(elem.Type()).Elem()
+			copied.Set(elem)
+			before := len(violations)
+			violations = personalizerValidate(copied, elemPath, violations)
+			if len(violations) > before {
+				v.SetMapIndex(iter.Key(), copied)
+			}
+		}
+	default:
+	}
+
+	return violations
+}
+
+func personalizerValidatePath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func personalizerValidateParse(tag string) *personalizerValidateOptions {
+	opts := &personalizerValidateOptions{policy: PersonalizerValidatePolicyReject}
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "oneof":
+			opts.oneOf = strings.Split(value, "|")
+		case "min":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				opts.min = &f
+			}
+		case "max":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				opts.max = &f
+			}
+		case "policy":
+			opts.policy = value
+		case "default":
+			opts.defaultValue = value
+		}
+	}
+	return opts
+}
+
+func personalizerValidateField(field reflect.Value, path string, opts *personalizerValidateOptions) *PersonalizerViolation {
+	if field.IsZero() {
+		return nil
+	}
+
+	var number float64
+	switch field.Kind() {
+	case reflect.String:
+		if len(opts.oneOf) == 0 || slices.Contains(opts.oneOf, field.String()) {
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number = float64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number = float64(field.Uint())
+	case reflect.Float32, reflect.Float64:
+		number = field.Float()
+	default:
+		return nil
+	}
+	if field.Kind() != reflect.String && (opts.min == nil || number >= *opts.min) && (opts.max == nil || number <= *opts.max) {
+		return nil
+	}
+
+	violation := &PersonalizerViolation{
+		Path:   path,
+		Value:  field.Interface(),
+		Policy: opts.policy,
+	}
+	switch {
+	case opts.policy == PersonalizerValidatePolicyClamp && field.Kind() != reflect.String:
+		if opts.min != nil && number < *opts.min {
+			number = *opts.min
+		}
+		if opts.max != nil && number > *opts.max {
+			number = *opts.max
+		}
+		personalizerValidateSet(field, strconv.FormatFloat(number, 'f', -1, 64))
+	case opts.policy == PersonalizerValidatePolicyDefault || opts.policy == PersonalizerValidatePolicyClamp:
+		personalizerValidateSet(field, opts.defaultValue)
+	default:
+		violation.Policy = PersonalizerValidatePolicyReject
+		return violation
+	}
+	violation.Resolved = field.Interface()
+	return violation
+}
+
+// personalizerValidateSet sets the field from its string form, or to its zero value if the string cannot be parsed.
+func personalizerValidateSet(field reflect.Value, value string) {
+	field.Set(reflect.Zero(field.Type()))
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			field.SetInt(int64(f))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+			field.SetUint(uint64(f))
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			field.SetFloat(f)
+		}
+	default:
+	}
+}
@@ -15,9 +15,14 @@
 package hiro
 
 import (
+	"cmp"
+	"container/list"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -31,6 +36,8 @@ var _ Publisher = (*SatoriPersonalizer)(nil)
 
 var _ Personalizer = (*SatoriPersonalizer)(nil)
 
+var _ StatSink = (*SatoriPersonalizer)(nil)
+
 type SatoriPersonalizerOption interface {
 	apply(*SatoriPersonalizer)
 }
@@ -171,6 +178,16 @@ func SatoriPersonalizerPublishStreaksEvents() SatoriPersonalizerOption {
 	}
 }
 
+// SatoriPersonalizerStatChangeEvents replaces the stats events published by the stats system with the per-stat change
+// events sent to the personalizer as a StatSink, so stat changes are not published twice.
+func SatoriPersonalizerStatChangeEvents() SatoriPersonalizerOption {
+	return &satoriPersonalizerOptionFunc{
+		f: func(personalizer *SatoriPersonalizer) {
+			personalizer.statChangeEvents = true
+		},
+	}
+}
+
 func SatoriPersonalizerPublishAllEvents() SatoriPersonalizerOption {
 	return &satoriPersonalizerOptionFunc{
 		f: func(personalizer *SatoriPersonalizer) {
@@ -187,9 +204,67 @@ func SatoriPersonalizerNoCache() SatoriPersonalizerOption {
 	}
 }
 
+// SatoriPersonalizerMaxCacheEntries limits the number of request contexts which have cached Satori values. When the
+// limit is exceeded the least recently used entry is evicted, regardless of whether its context has ended. This bounds
+// memory use during login storms with many distinct users.
+func SatoriPersonalizerMaxCacheEntries(n int) SatoriPersonalizerOption {
+	return &satoriPersonalizerOptionFunc{
+		f: func(personalizer *SatoriPersonalizer) {
+			personalizer.maxCacheEntries = n
+		},
+	}
+}
+
+// SatoriPersonalizerCacheTTL sets how long the Satori values cached for a request context are used. Older entries are
+// treated as a cache miss and fetched again, so flag values are refreshed within long-lived contexts. By default entries
+// are only evicted when their context ends.
+func SatoriPersonalizerCacheTTL(ttl time.Duration) SatoriPersonalizerOption {
+	return &satoriPersonalizerOptionFunc{
+		f: func(personalizer *SatoriPersonalizer) {
+			personalizer.cacheTTL = ttl
+		},
+	}
+}
+
+// SatoriPersonalizerPrefetchTTL sets how long values fetched by Prefetch are used for the user's later requests,
+// defaults to 60 seconds.
+func SatoriPersonalizerPrefetchTTL(ttl time.Duration) SatoriPersonalizerOption {
+	return &satoriPersonalizerOptionFunc{
+		f: func(personalizer *SatoriPersonalizer) {
+			personalizer.prefetchTTL = ttl
+		},
+	}
+}
+
+// SatoriPersonalizerPrefetchLiveEvents makes Prefetch fetch the user's live events together with their flags, so the
+// live events are also ready for the user's requests. By default only flags are prefetched.
+func SatoriPersonalizerPrefetchLiveEvents() SatoriPersonalizerOption {
+	return &satoriPersonalizerOptionFunc{
+		f: func(personalizer *SatoriPersonalizer) {
+			personalizer.prefetchLiveEvents = true
+		},
+	}
+}
+
+// SatoriPersonalizerDisableLiveEvents skips fetching live events from Satori, so all systems are only personalized
+// by their feature flags.
+func SatoriPersonalizerDisableLiveEvents() SatoriPersonalizerOption {
+	return &satoriPersonalizerOptionFunc{
+		f: func(personalizer *SatoriPersonalizer) {
+			personalizer.disableLiveEvents = true
+		},
+	}
+}
+
 type SatoriPersonalizerCache struct {
-	flags      map[string]unique.Handle[string]
-	liveEvents *atomic.Pointer[runtime.LiveEventList]
+	flags              map[string]unique.Handle[string]
+	liveEvents         *atomic.Pointer[runtime.LiveEventList]
+	liveEventsDisabled bool
+	// When the values in the entry were fetched from Satori.
+	createTime time.Time
+
+	// The entry's position in the least recently used list, only set when the cache size is limited.
+	element *list.Element
 }
 
 type SatoriPersonalizer struct {
@@ -213,10 +288,47 @@ type SatoriPersonalizer struct {
 	publishAuctionsEvents          bool
 	publishStreaksEvents           bool
 
-	noCache bool
+	statChangeEvents bool
+
+	noCache           bool
+	disableLiveEvents bool
 
 	cacheMutex sync.RWMutex
 	cache      map[context.Context]*SatoriPersonalizerCache
+	// The cached contexts ordered from most to least recently used, only maintained when the cache size is limited.
+	cacheLRU        *list.List
+	maxCacheEntries int
+	// How long cache entries are used, zero to keep them until their context ends.
+	cacheTTL time.Duration
+
+	// Values fetched ahead of the user's requests by Prefetch, keyed by user ID.
+	prefetchMutex sync.Mutex
+	prefetch      map[string]*satoriPrefetch
+	prefetchTTL   time.Duration
+	// Whether Prefetch also fetches live events.
+	prefetchLiveEvents bool
+
+	// Configs with live events applied, so the same live event values are not decoded again for each request.
+	liveEventDecodes sync.Map // satoriLiveEventKey -> *satoriLiveEventDecode
+}
+
+type satoriPrefetch struct {
+	cacheEntry    *SatoriPersonalizerCache
+	expiryTimeSec int64
+}
+
+type satoriLiveEventKey struct {
+	systemType SystemType
+	// The flag value applied before the live events, the zero handle if there is none.
+	flag       unique.Handle[string]
+	liveEvents [sha256.Size]byte
+}
+
+type satoriLiveEventDecode struct {
+	// The system config with the flag and live events applied, nil if none of them apply to the system.
+	config any
+	// Unix time the entry was last used, entries which have not been used since the previous sweep are removed.
+	lastUsed atomic.Int64
 }
 
 func (p *SatoriPersonalizer) Authenticate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, created bool) {
@@ -273,7 +385,7 @@ func (p *SatoriPersonalizer) Send(ctx context.Context, logger runtime.Logger, nk
 				continue
 			}
 		case SystemTypeStats:
-			if !p.IsPublishStatsEvents() {
+			if !p.IsPublishStatsEvents() || p.statChangeEvents {
 				continue
 			}
 		case SystemTypeEventLeaderboards:
@@ -302,7 +414,7 @@ func (p *SatoriPersonalizer) Send(ctx context.Context, logger runtime.Logger, nk
 		satoriEvent := &runtime.Event{
 			Name:      event.Name,
 			Id:        event.Id,
-			Metadata:  event.Metadata,
+			Metadata:  event.SchemaMetadata(),
 			Value:     event.Value,
 			Timestamp: event.Timestamp,
 		}
@@ -316,10 +428,53 @@ func (p *SatoriPersonalizer) Send(ctx context.Context, logger runtime.Logger, nk
 	}
 }
 
+// SatoriStatChangeEventName is the name of the Satori event published for each stat change.
+const SatoriStatChangeEventName = "statChange"
+
+// SendStatChanges publishes each stat change to Satori as an event with its before and after values, if stats events
+// are published. Register the personalizer with the stats system's AddStatSink, and use the
+// SatoriPersonalizerStatChangeEvents option to publish these in place of the coarser stats events.
+func (p *SatoriPersonalizer) SendStatChanges(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, changes []*StatChange) {
+	if !p.IsPublishStatsEvents() || len(changes) == 0 {
+		return
+	}
+
+	byUser := make(map[string][]*runtime.Event)
+	for _, change := range changes {
+		event := &PublisherEvent{
+			Name:      SatoriStatChangeEventName,
+			Timestamp: change.TimeSec,
+			Metadata: map[string]string{
+				"namespace": change.Namespace,
+				"name":      change.Name,
+				"old":       strconv.FormatInt(change.Old, 10),
+				"new":       strconv.FormatInt(change.New, 10),
+				"source":    change.Source.String(),
+			},
+			Value: strconv.FormatInt(change.New, 10),
+		}
+		byUser[change.UserId] = append(byUser[change.UserId], &runtime.Event{
+			Name:      event.Name,
+			Metadata:  event.SchemaMetadata(),
+			Value:     event.Value,
+			Timestamp: event.Timestamp,
+		})
+	}
+	for userID, events := range byUser {
+		if err := nk.GetSatori().EventsPublish(ctx, userID, events); err != nil {
+			logger.WithField("userID", userID).WithField("error", err.Error()).Error("failed to publish Satori stat change events")
+		}
+	}
+}
+
 func NewSatoriPersonalizer(ctx context.Context, opts ...SatoriPersonalizerOption) *SatoriPersonalizer {
 	s := &SatoriPersonalizer{
 		cacheMutex: sync.RWMutex{},
 		cache:      make(map[context.Context]*SatoriPersonalizerCache),
+		cacheLRU:   list.New(),
+
+		prefetch:    make(map[string]*satoriPrefetch),
+		prefetchTTL: 60 * time.Second,
 	}
 
 	// Apply options, if any supplied.
@@ -327,71 +482,96 @@ func NewSatoriPersonalizer(ctx context.Context, opts ...SatoriPersonalizerOption
 		opt.apply(s)
 	}
 
-	if !s.noCache {
-		go func() {
-			ticker := time.NewTicker(30 * time.Second)
-			defer ticker.Stop()
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case <-ticker.C:
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case t := <-ticker.C:
+				if !s.noCache {
 					s.cacheMutex.Lock()
-					for cacheCtx := range s.cache {
-						if cacheCtx.Err() != nil {
-							delete(s.cache, cacheCtx)
+					for cacheCtx, cacheEntry := range s.cache {
+						if cacheCtx.Err() != nil || s.cacheExpired(cacheEntry, t) {
+							s.cacheDelete(cacheCtx, cacheEntry)
 						}
 					}
 					s.cacheMutex.Unlock()
 				}
+
+				s.prefetchMutex.Lock()
+				for userID, prefetch := range s.prefetch {
+					if prefetch.expiryTimeSec <= t.Unix() {
+						delete(s.prefetch, userID)
+					}
+				}
+				s.prefetchMutex.Unlock()
+
+				// Configs for live events which are no longer in use, such as events which have ended, are removed.
+				expiry := t.Add(-30 * time.Second).Unix()
+				s.liveEventDecodes.Range(func(key, value any) bool {
+					if value.(*satoriLiveEventDecode).lastUsed.Load() < expiry {
+						s.liveEventDecodes.Delete(key)
+					}
+					return true
+				})
 			}
-		}()
-	}
+		}
+	}()
 
 	return s
 }
 
 var allFlagNames = []string{"Hiro-Achievements", "Hiro-Base", "Hiro-Economy", "Hiro-Energy", "Hiro-Inventory", "Hiro-Leaderboards", "Hiro-Teams", "Hiro-Tutorials", "Hiro-Unlockables", "Hiro-Stats", "Hiro-Event-Leaderboards", "Hiro-Progression", "Hiro-Incentives", "Hiro-Auctions", "Hiro-Streaks"}
 
-func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, system System, userID string) (any, error) {
-	var flagName string
-	switch system.GetType() {
+// satoriFlagName returns the name of the Satori flag which personalizes the system type.
+func satoriFlagName(systemType SystemType) (string, bool) {
+	switch systemType {
 	case SystemTypeAchievements:
-		flagName = "Hiro-Achievements"
+		return "Hiro-Achievements", true
 	case SystemTypeBase:
-		flagName = "Hiro-Base"
+		return "Hiro-Base", true
 	case SystemTypeEconomy:
-		flagName = "Hiro-Economy"
+		return "Hiro-Economy", true
 	case SystemTypeEnergy:
-		flagName = "Hiro-Energy"
+		return "Hiro-Energy", true
 	case SystemTypeInventory:
-		flagName = "Hiro-Inventory"
+		return "Hiro-Inventory", true
 	case SystemTypeLeaderboards:
-		flagName = "Hiro-Leaderboards"
+		return "Hiro-Leaderboards", true
 	case SystemTypeTeams:
-		flagName = "Hiro-Teams"
+		return "Hiro-Teams", true
 	case SystemTypeTutorials:
-		flagName = "Hiro-Tutorials"
+		return "Hiro-Tutorials", true
 	case SystemTypeUnlockables:
-		flagName = "Hiro-Unlockables"
+		return "Hiro-Unlockables", true
 	case SystemTypeStats:
-		flagName = "Hiro-Stats"
+		return "Hiro-Stats", true
 	case SystemTypeEventLeaderboards:
-		flagName = "Hiro-Event-Leaderboards"
+		return "Hiro-Event-Leaderboards", true
 	case SystemTypeProgression:
-		flagName = "Hiro-Progression"
+		return "Hiro-Progression", true
 	case SystemTypeIncentives:
-		flagName = "Hiro-Incentives"
+		return "Hiro-Incentives", true
 	case SystemTypeAuctions:
-		flagName = "Hiro-Auctions"
+		return "Hiro-Auctions", true
 	case SystemTypeStreaks:
-		flagName = "Hiro-Streaks"
+		return "Hiro-Streaks", true
 	default:
+		return "", false
+	}
+}
+
+func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, system System, userID string) (any, error) {
+	flagName, ok := satoriFlagName(system.GetType())
+	if !ok {
 		return nil, runtime.NewError("hiro system type unknown", 3)
 	}
 
-	var config any
-	var found bool
+	var flag unique.Handle[string]
+	var flagFound bool
+	var liveEventsList *runtime.LiveEventList
 
 	if p.noCache {
 		flagList, err := nk.GetSatori().FlagsList(ctx, userID, flagName)
@@ -405,19 +585,12 @@ func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger
 		}
 
 		if len(flagList.Flags) >= 1 {
-			config = system.GetConfig()
-			decoder := json.NewDecoder(strings.NewReader(flagList.Flags[0].Value))
-			decoder.DisallowUnknownFields()
-			if err := decoder.Decode(config); err != nil {
-				logger.WithField("userID", userID).WithField("error", err.Error()).Error("error merging Satori flag value")
-				return nil, err
-			}
-			found = true
+			flag, flagFound = unique.Make[string](flagList.Flags[0].Value), true
 		}
 
-		if s := system.GetType(); s == SystemTypeEventLeaderboards || s == SystemTypeAchievements {
+		if s := system.GetType(); !p.disableLiveEvents && (s == SystemTypeEventLeaderboards || s == SystemTypeAchievements) {
 			// If looking at event leaderboards, also load live events.
-			liveEventsList, err := nk.GetSatori().LiveEventsList(ctx, userID)
+			liveEventsList, err = nk.GetSatori().LiveEventsList(ctx, userID)
 			if err != nil {
 				if strings.Contains(err.Error(), "404 status code") {
 					logger.WithField("userID", userID).WithField("error", err.Error()).Warn("error requesting Satori live events list, user not found")
@@ -426,26 +599,19 @@ func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger
 				logger.WithField("userID", userID).WithField("error", err.Error()).Error("error requesting Satori live events list")
 				return nil, err
 			}
-			if len(liveEventsList.LiveEvents) > 0 {
-				if config == nil {
-					config = system.GetConfig()
-				}
-				for _, liveEvent := range liveEventsList.LiveEvents {
-					decoder := json.NewDecoder(strings.NewReader(liveEvent.Value))
-					decoder.DisallowUnknownFields()
-					if err := decoder.Decode(config); err != nil {
-						// The live event may be intended for a different purpose, do not log or return an error here.
-						continue
-					}
-					found = true
-				}
-			}
 		}
 	} else {
-		var cacheEntry *SatoriPersonalizerCache
-		p.cacheMutex.RLock()
-		cacheEntry, found = p.cache[ctx]
-		p.cacheMutex.RUnlock()
+		cacheEntry, found := p.cacheGet(ctx)
+		if found && cacheEntry.liveEventsDisabled != p.disableLiveEvents {
+			// The cache entry was populated with a different live events setting, do not use it.
+			found = false
+		}
+
+		if !found {
+			if cacheEntry, found = p.prefetchGet(userID); found {
+				p.cachePut(ctx, cacheEntry)
+			}
+		}
 
 		if !found {
 			flagList, err := nk.GetSatori().FlagsList(ctx, userID, allFlagNames...)
@@ -459,7 +625,7 @@ func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger
 			}
 
 			var liveEventsList *runtime.LiveEventList
-			if s := system.GetType(); s == SystemTypeEventLeaderboards || s == SystemTypeAchievements {
+			if s := system.GetType(); !p.disableLiveEvents && (s == SystemTypeEventLeaderboards || s == SystemTypeAchievements) {
 				liveEventsList, err = nk.GetSatori().LiveEventsList(ctx, userID)
 				if err != nil {
 					if strings.Contains(err.Error(), "404 status code") {
@@ -473,7 +639,9 @@ func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger
 
 			cacheEntry = &SatoriPersonalizerCache{
 				// flags set below.
-				liveEvents: &atomic.Pointer[runtime.LiveEventList]{},
+				liveEvents:         &atomic.Pointer[runtime.LiveEventList]{},
+				liveEventsDisabled: p.disableLiveEvents,
+				createTime:         time.Now(),
 			}
 			if flagList != nil {
 				cacheEntry.flags = make(map[string]unique.Handle[string], len(flagList.Flags))
@@ -484,12 +652,10 @@ func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger
 			if liveEventsList != nil {
 				cacheEntry.liveEvents.Store(liveEventsList)
 			}
-			p.cacheMutex.Lock()
-			p.cache[ctx] = cacheEntry
-			p.cacheMutex.Unlock()
+			p.cachePut(ctx, cacheEntry)
 		}
 
-		if s := system.GetType(); (s == SystemTypeEventLeaderboards || s == SystemTypeAchievements) && cacheEntry.liveEvents.Load() == nil {
+		if s := system.GetType(); !cacheEntry.liveEventsDisabled && (s == SystemTypeEventLeaderboards || s == SystemTypeAchievements) && cacheEntry.liveEvents.Load() == nil {
 			liveEventsList, err := nk.GetSatori().LiveEventsList(ctx, userID)
 			if err != nil {
 				if strings.Contains(err.Error(), "404 status code") {
@@ -502,44 +668,481 @@ func (p *SatoriPersonalizer) GetValue(ctx context.Context, logger runtime.Logger
 			cacheEntry.liveEvents.Store(liveEventsList)
 		}
 
-		found = false
+		flag, flagFound = cacheEntry.flags[flagName]
+		if !cacheEntry.liveEventsDisabled {
+			liveEventsList = cacheEntry.liveEvents.Load()
+		}
+	}
 
-		for flName, flHandle := range cacheEntry.flags {
-			if flName != flagName {
-				continue
+	if flagFound && PersonalizerValueEmpty(flag.Value()) {
+		logger.WithField("userID", userID).WithField("flag", flagName).Debug("empty Satori flag value, no override")
+		flagFound = false
+	}
+
+	var config any
+	var found bool
+	if liveEventsList != nil && len(liveEventsList.LiveEvents) > 0 {
+		var err error
+		if config, found, err = p.applyLiveEvents(system, flag, flagFound, liveEventsList.LiveEvents); err != nil {
+			logger.WithField("userID", userID).WithField("error", err.Error()).Error("error merging Satori flag value")
+			return nil, err
+		}
+	} else if flagFound {
+		config = system.GetConfig()
+		if err := PersonalizerDecode(flag.Value(), config); err != nil {
+			logger.WithField("userID", userID).WithField("error", err.Error()).Error("error merging Satori flag value")
+			return nil, err
+		}
+		found = true
+	}
+
+	// If this caller doesn't have the given flag (or live events) return the nil to indicate no change to the config.
+	if !found {
+		return nil, nil
+	}
+
+	violations, reject := PersonalizerValidate(config)
+	for _, violation := range violations {
+		logger.WithFields(map[string]any{
+			"userID":   userID,
+			"path":     violation.Path,
+			"value":    violation.Value,
+			"policy":   violation.Policy,
+			"resolved": violation.Resolved,
+		}).Warn("invalid value in personalized config")
+	}
+	if reject {
+		// Use the base config rather than propagate an invalid value into the system.
+		logger.WithField("userID", userID).WithField("flag", flagName).Error("personalized config rejected, invalid values")
+		return nil, nil
+	}
+
+	return config, nil
+}
+
+// Prefetch fetches the user's Satori flags, and live events if enabled with SatoriPersonalizerPrefetchLiveEvents, ahead
+// of their requests so the first requests after authentication do not wait for Satori. It's intended to be called from
+// the authentication hook. The values are used by the user's requests for the prefetch TTL.
+func (p *SatoriPersonalizer) Prefetch(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) error {
+	if p.noCache {
+		return nil
+	}
+
+	flagList, err := nk.GetSatori().FlagsList(ctx, userID, allFlagNames...)
+	if err != nil {
+		if strings.Contains(err.Error(), "404 status code") {
+			logger.WithField("userID", userID).WithField("error", err.Error()).Warn("error requesting Satori flag list, user not found")
+			return nil
+		}
+		logger.WithField("userID", userID).WithField("error", err.Error()).Error("error requesting Satori flag list")
+		return err
+	}
+
+	cacheEntry := &SatoriPersonalizerCache{
+		liveEvents:         &atomic.Pointer[runtime.LiveEventList]{},
+		liveEventsDisabled: p.disableLiveEvents,
+		createTime:         time.Now(),
+	}
+	if flagList != nil {
+		cacheEntry.flags = make(map[string]unique.Handle[string], len(flagList.Flags))
+		for _, flag := range flagList.Flags {
+			cacheEntry.flags[flag.Name] = unique.Make[string](flag.Value)
+		}
+	}
+
+	if p.prefetchLiveEvents && !p.disableLiveEvents {
+		liveEventsList, err := nk.GetSatori().LiveEventsList(ctx, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "404 status code") {
+				logger.WithField("userID", userID).WithField("error", err.Error()).Warn("error requesting Satori live events list, user not found")
+				return nil
 			}
+			logger.WithField("userID", userID).WithField("error", err.Error()).Error("error requesting Satori live events list")
+			return err
+		}
+		cacheEntry.liveEvents.Store(liveEventsList)
+	}
 
-			config = system.GetConfig()
-			decoder := json.NewDecoder(strings.NewReader(flHandle.Value()))
-			decoder.DisallowUnknownFields()
-			if err := decoder.Decode(config); err != nil {
-				logger.WithField("userID", userID).WithField("error", err.Error()).Error("error merging Satori flag value")
-				return nil, err
+	p.prefetchMutex.Lock()
+	if previous, found := p.prefetch[userID]; found {
+		p.invalidateLiveEvents(previous.cacheEntry.liveEvents.Load(), cacheEntry.liveEvents.Load())
+	}
+	p.prefetch[userID] = &satoriPrefetch{
+		cacheEntry:    cacheEntry,
+		expiryTimeSec: time.Now().Add(p.prefetchTTL).Unix(),
+	}
+	p.prefetchMutex.Unlock()
+
+	return nil
+}
+
+// prefetchGet returns a new cache entry for a request context with the values prefetched for the user, if any. Values
+// prefetched longer ago than the prefetch TTL or the cache TTL are a miss.
+func (p *SatoriPersonalizer) prefetchGet(userID string) (*SatoriPersonalizerCache, bool) {
+	p.prefetchMutex.Lock()
+	prefetch, found := p.prefetch[userID]
+	p.prefetchMutex.Unlock()
+	now := time.Now()
+	if !found || prefetch.expiryTimeSec <= now.Unix() || p.cacheExpired(prefetch.cacheEntry, now) || prefetch.cacheEntry.liveEventsDisabled != p.disableLiveEvents {
+		return nil, false
+	}
+
+	// Each request context has its own entry, the prefetched flags are immutable and can be shared.
+	cacheEntry := &SatoriPersonalizerCache{
+		flags:              prefetch.cacheEntry.flags,
+		liveEvents:         &atomic.Pointer[runtime.LiveEventList]{},
+		liveEventsDisabled: prefetch.cacheEntry.liveEventsDisabled,
+		createTime:         prefetch.cacheEntry.createTime,
+	}
+	if liveEventsList := prefetch.cacheEntry.liveEvents.Load(); liveEventsList != nil {
+		cacheEntry.liveEvents.Store(liveEventsList)
+	}
+	return cacheEntry, true
+}
+
+// cacheGet returns the cache entry for the request context, and marks it as the most recently used. An entry older
+// than the cache TTL is a miss, and is replaced when the values are fetched again.
+func (p *SatoriPersonalizer) cacheGet(ctx context.Context) (*SatoriPersonalizerCache, bool) {
+	if p.maxCacheEntries <= 0 {
+		p.cacheMutex.RLock()
+		cacheEntry, found := p.cache[ctx]
+		p.cacheMutex.RUnlock()
+		if found && p.cacheExpired(cacheEntry, time.Now()) {
+			return nil, false
+		}
+		return cacheEntry, found
+	}
+
+	p.cacheMutex.Lock()
+	cacheEntry, found := p.cache[ctx]
+	if found && p.cacheExpired(cacheEntry, time.Now()) {
+		// The expired entry is left to be replaced by cachePut, so its live events can be compared with the new ones.
+		cacheEntry, found = nil, false
+	}
+	if found && cacheEntry.element != nil {
+		p.cacheLRU.MoveToFront(cacheEntry.element)
+	}
+	p.cacheMutex.Unlock()
+	return cacheEntry, found
+}
+
+// cacheExpired returns true if the cache entry is older than the cache TTL at the given time.
+func (p *SatoriPersonalizer) cacheExpired(cacheEntry *SatoriPersonalizerCache, now time.Time) bool {
+	return p.cacheTTL > 0 && now.Sub(cacheEntry.createTime) >= p.cacheTTL
+}
+
+// cachePut stores the cache entry for the request context, and evicts the least recently used entries if the cache
+// size is limited and has been exceeded.
+func (p *SatoriPersonalizer) cachePut(ctx context.Context, cacheEntry *SatoriPersonalizerCache) {
+	p.cacheMutex.Lock()
+	if previous, found := p.cache[ctx]; found {
+		p.cacheDelete(ctx, previous)
+		p.invalidateLiveEvents(previous.liveEvents.Load(), cacheEntry.liveEvents.Load())
+	}
+	p.cache[ctx] = cacheEntry
+	if p.maxCacheEntries > 0 {
+		cacheEntry.element = p.cacheLRU.PushFront(ctx)
+		for len(p.cache) > p.maxCacheEntries {
+			oldest := p.cacheLRU.Back()
+			oldestCtx := oldest.Value.(context.Context)
+			p.cacheDelete(oldestCtx, p.cache[oldestCtx])
+		}
+	}
+	p.cacheMutex.Unlock()
+}
+
+// cacheDelete removes the cache entry for the request context. Must be called with cacheMutex held.
+func (p *SatoriPersonalizer) cacheDelete(ctx context.Context, cacheEntry *SatoriPersonalizerCache) {
+	if cacheEntry != nil && cacheEntry.element != nil {
+		p.cacheLRU.Remove(cacheEntry.element)
+	}
+	delete(p.cache, ctx)
+}
+
+// satoriLiveEventValues returns the values of the live events in the order they're applied, by ascending priority so
+// the highest priority is applied last and wins. Live events with the same priority are applied in order of their
+// active start time and then their ID, so the most recently started live event wins a tie.
+func satoriLiveEventValues(liveEvents []*runtime.LiveEvent) []string {
+	type prioritized struct {
+		liveEvent *runtime.LiveEvent
+		priority  int
+		value     string
+	}
+	ordered := make([]prioritized, 0, len(liveEvents))
+	for _, liveEvent := range liveEvents {
+		if liveEvent == nil {
+			continue
+		}
+		priority, value := PersonalizerLiveEventPriority(liveEvent.Value)
+		ordered = append(ordered, prioritized{liveEvent: liveEvent, priority: priority, value: value})
+	}
+	slices.SortStableFunc(ordered, func(a, b prioritized) int {
+		if c := cmp.Compare(a.priority, b.priority); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.liveEvent.ActiveStartTimeSec, b.liveEvent.ActiveStartTimeSec); c != 0 {
+			return c
+		}
+		return strings.Compare(a.liveEvent.Id, b.liveEvent.Id)
+	})
+
+	values := make([]string, 0, len(ordered))
+	for _, o := range ordered {
+		values = append(values, o.value)
+	}
+	return values
+}
+
+// applyLiveEvents returns the system config with the flag value, if found, and the live events applied over it, and
+// whether the flag or any live event was applied. Live events which do not apply to the system are skipped, as they
+// may be intended for a different purpose.
+//
+// The result is cached by the hash of the flag and live event values, so repeated requests with the same values reuse
+// it rather than decoding large live event values again. Each caller is returned its own copy of the cached config.
+func (p *SatoriPersonalizer) applyLiveEvents(system System, flag unique.Handle[string], flagFound bool, liveEvents []*runtime.LiveEvent) (any, bool, error) {
+	key := satoriLiveEventKey{systemType: system.GetType(), liveEvents: satoriLiveEventsHash(liveEvents)}
+	if flagFound {
+		key.flag = flag
+	}
+	now := time.Now().Unix()
+
+	if cached, found := p.liveEventDecodes.Load(key); found {
+		decode := cached.(*satoriLiveEventDecode)
+		decode.lastUsed.Store(now)
+		if decode.config == nil {
+			return nil, false, nil
+		}
+		return personalizerCopy(decode.config), true, nil
+	}
+
+	config := system.GetConfig()
+	found := flagFound
+	if flagFound {
+		if err := PersonalizerDecode(flag.Value(), config); err != nil {
+			return nil, false, err
+		}
+	}
+	for _, value := range satoriLiveEventValues(liveEvents) {
+		applied, err := applyLiveEvent(value, config)
+		if err != nil {
+			// The live event may be intended for a different purpose, do not log or return an error here.
+			continue
+		}
+		config, found = applied, true
+	}
+
+	decode := &satoriLiveEventDecode{}
+	if found {
+		decode.config = config
+	}
+	decode.lastUsed.Store(now)
+	p.liveEventDecodes.Store(key, decode)
+
+	if !found {
+		return nil, false, nil
+	}
+	return personalizerCopy(config), true, nil
+}
+
+// applyLiveEvent decodes a live event value onto a copy of the config, and returns the copy. The config is unchanged
+// if the value does not apply to the system, so a value which fails part way through decoding is never partially
+// applied.
+func applyLiveEvent(value string, config any) (any, error) {
+	applied := personalizerCopy(config)
+	if err := PersonalizerDecode(value, applied); err != nil {
+		return config, err
+	}
+	return applied, nil
+}
+
+// satoriLiveEventsHash returns a hash of the IDs, start times, and values of the live events, which determine the
+// values applied and their order.
+func satoriLiveEventsHash(liveEvents []*runtime.LiveEvent) [sha256.Size]byte {
+	h := sha256.New()
+	var buf []byte
+	for _, liveEvent := range liveEvents {
+		if liveEvent == nil {
+			continue
+		}
+		buf = binary.AppendUvarint(buf[:0], uint64(len(liveEvent.Id)))
+		buf = append(buf, liveEvent.Id...)
+		buf = binary.AppendVarint(buf, liveEvent.ActiveStartTimeSec)
+		buf = binary.AppendUvarint(buf, uint64(len(liveEvent.Value)))
+		h.Write(buf)
+		h.Write([]byte(liveEvent.Value))
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// invalidateLiveEvents removes the configs cached for a live event list which has been replaced by a different list,
+// such as when a user's values are fetched from Satori again.
+func (p *SatoriPersonalizer) invalidateLiveEvents(previous, current *runtime.LiveEventList) {
+	if previous == nil || len(previous.LiveEvents) == 0 {
+		return
+	}
+	previousHash := satoriLiveEventsHash(previous.LiveEvents)
+	if current != nil && satoriLiveEventsHash(current.LiveEvents) == previousHash {
+		return
+	}
+
+	p.liveEventDecodes.Range(func(key, _ any) bool {
+		if key.(satoriLiveEventKey).liveEvents == previousHash {
+			p.liveEventDecodes.Delete(key)
+		}
+		return true
+	})
+}
+
+// Apply decodes the value of a Satori flag for the user onto the target, which can be any config struct held by game
+// code outside of the Hiro systems. The same merge strategies and unknown field checks are used as for system configs.
+// Hiro flags already fetched in the request are reused, otherwise the flag is fetched from Satori.
+//
+// The target is unchanged and false is returned if the user does not have the flag or its value is empty.
+func (p *SatoriPersonalizer) Apply(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, flagName string, target any) (bool, error) {
+	var value string
+	var found bool
+
+	if !p.noCache {
+		cacheEntry, cacheFound := p.cacheGet(ctx)
+		if cacheFound {
+			if flHandle, flFound := cacheEntry.flags[flagName]; flFound {
+				value = flHandle.Value()
+				found = true
 			}
-			found = true
 		}
+	}
 
-		if liveEventsList := cacheEntry.liveEvents.Load(); liveEventsList != nil && len(liveEventsList.LiveEvents) > 0 {
-			if config == nil {
-				config = system.GetConfig()
+	if !found {
+		flagList, err := nk.GetSatori().FlagsList(ctx, userID, flagName)
+		if err != nil {
+			if strings.Contains(err.Error(), "404 status code") {
+				logger.WithField("userID", userID).WithField("error", err.Error()).Warn("error requesting Satori flag list, user not found")
+				return false, nil
 			}
-			for _, liveEvent := range liveEventsList.LiveEvents {
-				decoder := json.NewDecoder(strings.NewReader(liveEvent.Value))
-				decoder.DisallowUnknownFields()
-				if err := decoder.Decode(config); err != nil {
-					// The live event may be intended for a different purpose, do not log or return an error here.
-					continue
-				}
+			logger.WithField("userID", userID).WithField("error", err.Error()).Error("error requesting Satori flag list")
+			return false, err
+		}
+		for _, flag := range flagList.Flags {
+			if flag.Name == flagName {
+				value = flag.Value
 				found = true
+				break
 			}
 		}
 	}
 
-	// If this caller doesn't have the given flag (or live events) return the nil to indicate no change to the config.
 	if !found {
+		return false, nil
+	}
+	if PersonalizerValueEmpty(value) {
+		logger.WithField("userID", userID).WithField("flag", flagName).Debug("empty Satori flag value, no override")
+		return false, nil
+	}
+
+	if err := PersonalizerDecode(value, target); err != nil {
+		logger.WithField("userID", userID).WithField("error", err.Error()).Error("error merging Satori flag value")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ActiveLiveEvents returns the user's live events which are active now, such as to show event banners. Live events
+// already fetched in the request or prefetched for the user are reused, otherwise they're fetched from Satori and kept
+// for the rest of the request. No live events are returned if live events are disabled.
+func (p *SatoriPersonalizer) ActiveLiveEvents(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) ([]*runtime.LiveEvent, error) {
+	if p.disableLiveEvents {
+		return nil, nil
+	}
+
+	var cacheEntry *SatoriPersonalizerCache
+	var liveEventsList *runtime.LiveEventList
+	if !p.noCache {
+		var found bool
+		cacheEntry, found = p.cacheGet(ctx)
+		if found && cacheEntry.liveEventsDisabled != p.disableLiveEvents {
+			// The cache entry was populated with a different live events setting, do not use it.
+			cacheEntry, found = nil, false
+		}
+		if !found {
+			if cacheEntry, found = p.prefetchGet(userID); found {
+				p.cachePut(ctx, cacheEntry)
+			}
+		}
+		if found {
+			liveEventsList = cacheEntry.liveEvents.Load()
+		}
+	}
+
+	if liveEventsList == nil {
+		var err error
+		liveEventsList, err = nk.GetSatori().LiveEventsList(ctx, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "404 status code") {
+				logger.WithField("userID", userID).WithField("error", err.Error()).Warn("error requesting Satori live events list, user not found")
+				return nil, nil
+			}
+			logger.WithField("userID", userID).WithField("error", err.Error()).Error("error requesting Satori live events list")
+			return nil, err
+		}
+		// Only an existing entry is updated, a new entry without flags would hide the flags from GetValue.
+		if cacheEntry != nil {
+			cacheEntry.liveEvents.Store(liveEventsList)
+		}
+	}
+	if liveEventsList == nil {
 		return nil, nil
 	}
 
+	now := time.Now().Unix()
+	active := make([]*runtime.LiveEvent, 0, len(liveEventsList.LiveEvents))
+	for _, liveEvent := range liveEventsList.LiveEvents {
+		if liveEvent == nil || liveEvent.ActiveStartTimeSec > now || (liveEvent.ActiveEndTimeSec > 0 && liveEvent.ActiveEndTimeSec <= now) {
+			continue
+		}
+		active = append(active, liveEvent)
+	}
+	return active, nil
+}
+
+// SimulateLiveEvent applies a live event value onto the config of the given system in the same way GetValue does, and
+// returns the result without making any requests to Satori. The user's flag value for the system is applied first if
+// it was already fetched in the request or prefetched for the user, then the event value, and the result is validated.
+// Unlike GetValue an event value which cannot be applied to the system config, or a result which fails validation
+// with the reject policy, is returned as an error so event values can be checked before they go live.
+func (p *SatoriPersonalizer) SimulateLiveEvent(ctx context.Context, userID string, eventValue string, system System) (any, error) {
+	flagName, ok := satoriFlagName(system.GetType())
+	if !ok {
+		return nil, runtime.NewError("hiro system type unknown", 3)
+	}
+
+	config := system.GetConfig()
+	if !p.noCache {
+		cacheEntry, found := p.cacheGet(ctx)
+		if !found {
+			cacheEntry, found = p.prefetchGet(userID)
+		}
+		if found {
+			if flHandle, flFound := cacheEntry.flags[flagName]; flFound && !PersonalizerValueEmpty(flHandle.Value()) {
+				if err := PersonalizerDecode(flHandle.Value(), config); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	_, eventValue = PersonalizerLiveEventPriority(eventValue)
+	config, err := applyLiveEvent(eventValue, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, reject := PersonalizerValidate(config); reject {
+		return nil, ErrPersonalizerConfigRejected
+	}
+
 	return config, nil
 }
 
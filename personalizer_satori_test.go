@@ -0,0 +1,515 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+type testPersonalizedConfig struct {
+	Name  string   `json:"name,omitempty"`
+	Mode  string   `json:"mode,omitempty" hirovalidate:"oneof=daily|weekly"`
+	Scale float64  `json:"scale,omitempty" hirovalidate:"max=10,policy=clamp"`
+	Tags  []string `json:"tags,omitempty" hiro:"merge=append"`
+}
+
+func newTestPersonalizedSystem(systemType SystemType) *testSystem {
+	return &testSystem{
+		systemType: systemType,
+		config: func() any {
+			return &testPersonalizedConfig{Name: "base", Mode: "daily", Scale: 1, Tags: []string{"base"}}
+		},
+	}
+}
+
+func TestSatoriPersonalizerSimulateLiveEvent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewSatoriPersonalizer(ctx)
+	nk := newTestNakamaModule([]*runtime.Flag{{Name: "Hiro-Event-Leaderboards", Value: `{"name":"flag","tags":["flag"]}`}}, nil)
+	system := newTestPersonalizedSystem(SystemTypeEventLeaderboards)
+	if err := p.Prefetch(ctx, &testLogger{}, nk, "user"); err != nil {
+		t.Fatalf("prefetch failed: %v", err)
+	}
+
+	result, err := p.SimulateLiveEvent(context.Background(), "user", `{"hiro_priority":5,"tags":["event"],"scale":20}`, system)
+	if err != nil {
+		t.Fatalf("simulate failed: %v", err)
+	}
+	config := result.(*testPersonalizedConfig)
+	if config.Name != "flag" {
+		t.Errorf("name = %q, want the prefetched flag value applied", config.Name)
+	}
+	if !slices.Equal(config.Tags, []string{"base", "flag", "event"}) {
+		t.Errorf("tags = %v, want [base flag event]", config.Tags)
+	}
+	if config.Scale != 10 {
+		t.Errorf("scale = %v, want it clamped to 10", config.Scale)
+	}
+	if flagsCalls, liveEventsCalls := nk.satori.calls(); flagsCalls != 1 || liveEventsCalls != 0 {
+		t.Errorf("made %d flag and %d live event requests, want only the prefetch", flagsCalls, liveEventsCalls)
+	}
+
+	if _, err := p.SimulateLiveEvent(ctx, "user", `{"mode":"hourly"}`, system); !errors.Is(err, ErrPersonalizerConfigRejected) {
+		t.Errorf("simulate with an invalid mode returned %v, want %v", err, ErrPersonalizerConfigRejected)
+	}
+	if _, err := p.SimulateLiveEvent(ctx, "user", `{"unknown":true}`, system); err == nil {
+		t.Error("simulate with an unknown field succeeded")
+	}
+}
+
+func TestSatoriPersonalizerEmptyValue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, value := range []string{"", "  \n\t"} {
+		nk := newTestNakamaModule([]*runtime.Flag{{Name: "Hiro-Economy", Value: value}}, nil)
+		system := newTestPersonalizedSystem(SystemTypeEconomy)
+
+		for _, p := range []*SatoriPersonalizer{NewSatoriPersonalizer(ctx), NewSatoriPersonalizer(ctx, SatoriPersonalizerNoCache())} {
+			config, err := p.GetValue(context.Background(), &testLogger{}, nk, system, "user")
+			if err != nil {
+				t.Fatalf("get value for %q failed: %v", value, err)
+			}
+			if config != nil {
+				t.Errorf("get value for %q returned %+v, want no override", value, config)
+			}
+		}
+
+		target := &testPersonalizedConfig{Name: "target"}
+		applied, err := NewSatoriPersonalizer(ctx).Apply(context.Background(), &testLogger{}, nk, "user", "Hiro-Economy", target)
+		if err != nil || applied || target.Name != "target" {
+			t.Errorf("apply for %q = %v, %v, want no override", value, applied, err)
+		}
+	}
+}
+
+func TestSatoriPersonalizerApply(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewSatoriPersonalizer(ctx)
+	nk := newTestNakamaModule([]*runtime.Flag{
+		{Name: "Game-Tuning", Value: `{"name":"tuned","tags":["tuned"]}`},
+		{Name: "Hiro-Economy", Value: `{"scale":2}`},
+	}, nil)
+
+	target := &testPersonalizedConfig{Name: "target", Tags: []string{"target"}}
+	applied, err := p.Apply(ctx, &testLogger{}, nk, "user", "Game-Tuning", target)
+	if err != nil || !applied {
+		t.Fatalf("apply = %v, %v, want applied", applied, err)
+	}
+	if target.Name != "tuned" || !slices.Equal(target.Tags, []string{"target", "tuned"}) {
+		t.Errorf("target = %+v, want the flag merged onto it", target)
+	}
+
+	if applied, err = p.Apply(ctx, &testLogger{}, nk, "user", "Game-Missing", target); err != nil || applied {
+		t.Errorf("apply of a missing flag = %v, %v, want not applied", applied, err)
+	}
+	if _, err = p.Apply(ctx, &testLogger{}, nk, "user", "Game-Tuning", &struct{}{}); err == nil {
+		t.Error("apply onto a struct without the flag's fields succeeded")
+	}
+
+	// Hiro flags already fetched in the request are reused.
+	if _, err = p.GetValue(ctx, &testLogger{}, nk, newTestPersonalizedSystem(SystemTypeEconomy), "user"); err != nil {
+		t.Fatalf("get value failed: %v", err)
+	}
+	flagsCalls, _ := nk.satori.calls()
+	target = &testPersonalizedConfig{}
+	if applied, err = p.Apply(ctx, &testLogger{}, nk, "user", "Hiro-Economy", target); err != nil || !applied || target.Scale != 2 {
+		t.Errorf("apply of a cached flag = %v, %v, scale %v, want applied with scale 2", applied, err, target.Scale)
+	}
+	if calls, _ := nk.satori.calls(); calls != flagsCalls {
+		t.Errorf("apply of a cached flag made %d flag requests, want none", calls-flagsCalls)
+	}
+}
+
+// testDecodeCounter counts how many times a value containing it is decoded.
+type testDecodeCounter int
+
+var testDecodes atomic.Int64
+
+func (c *testDecodeCounter) UnmarshalJSON(data []byte) error {
+	testDecodes.Add(1)
+	return json.Unmarshal(data, (*int)(c))
+}
+
+type testLiveEventConfig struct {
+	Name    string            `json:"name,omitempty"`
+	Counted testDecodeCounter `json:"counted,omitempty"`
+	Tags    []string          `json:"tags,omitempty" hiro:"merge=append"`
+}
+
+func newTestLiveEventSystem() *testSystem {
+	return &testSystem{
+		systemType: SystemTypeEventLeaderboards,
+		config: func() any {
+			return &testLiveEventConfig{Name: "base", Tags: []string{"base"}}
+		},
+	}
+}
+
+func TestSatoriPersonalizerLiveEventDecodes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	liveEvents := []*runtime.LiveEvent{
+		{Id: "large", Value: `{"counted":1,"tags":["large"]}`, ActiveStartTimeSec: 1},
+		{Id: "partial", Value: `{"name":"partial","unknown":true}`, ActiveStartTimeSec: 2},
+	}
+	nk := newTestNakamaModule([]*runtime.Flag{{Name: "Hiro-Event-Leaderboards", Value: `{"name":"flag"}`}}, liveEvents)
+	system := newTestLiveEventSystem()
+
+	for _, p := range []*SatoriPersonalizer{NewSatoriPersonalizer(ctx), NewSatoriPersonalizer(ctx, SatoriPersonalizerNoCache())} {
+		testDecodes.Store(0)
+		for i := 0; i < 5; i++ {
+			result, err := p.GetValue(testRequestContext(t), &testLogger{}, nk, system, "user")
+			if err != nil {
+				t.Fatalf("get value failed: %v", err)
+			}
+			config := result.(*testLiveEventConfig)
+			// The partial live event does not apply, so none of its fields are applied.
+			if config.Name != "flag" || config.Counted != 1 || !slices.Equal(config.Tags, []string{"base", "large"}) {
+				t.Fatalf("get value %d returned %+v, want the flag and large live event applied", i, config)
+			}
+			// Each caller has its own copy.
+			config.Tags[0] = "changed"
+		}
+		if decodes := testDecodes.Load(); decodes != 1 {
+			t.Errorf("live event value decoded %d times for repeated requests, want 1", decodes)
+		}
+	}
+}
+
+func TestSatoriPersonalizerLiveEventInvalidation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewSatoriPersonalizer(ctx, SatoriPersonalizerPrefetchLiveEvents())
+	nk := newTestNakamaModule(nil, []*runtime.LiveEvent{{Id: "first", Value: `{"counted":1,"name":"first"}`}})
+	system := newTestLiveEventSystem()
+
+	cached := func() int {
+		var n int
+		p.liveEventDecodes.Range(func(any, any) bool {
+			n++
+			return true
+		})
+		return n
+	}
+
+	if err := p.Prefetch(ctx, &testLogger{}, nk, "user"); err != nil {
+		t.Fatalf("prefetch failed: %v", err)
+	}
+	if _, err := p.GetValue(testRequestContext(t), &testLogger{}, nk, system, "user"); err != nil {
+		t.Fatalf("get value failed: %v", err)
+	}
+	if n := cached(); n != 1 {
+		t.Fatalf("%d configs cached, want 1", n)
+	}
+
+	// Prefetching an unchanged list keeps the cached config.
+	if err := p.Prefetch(ctx, &testLogger{}, nk, "user"); err != nil {
+		t.Fatalf("prefetch failed: %v", err)
+	}
+	if n := cached(); n != 1 {
+		t.Fatalf("%d configs cached after prefetching the same live events, want 1", n)
+	}
+
+	nk.satori.setLiveEvents([]*runtime.LiveEvent{{Id: "second", Value: `{"counted":2,"name":"second"}`}})
+	if err := p.Prefetch(ctx, &testLogger{}, nk, "user"); err != nil {
+		t.Fatalf("prefetch failed: %v", err)
+	}
+	if n := cached(); n != 0 {
+		t.Errorf("%d configs cached after the live events changed, want 0", n)
+	}
+
+	testDecodes.Store(0)
+	result, err := p.GetValue(testRequestContext(t), &testLogger{}, nk, system, "user")
+	if err != nil {
+		t.Fatalf("get value failed: %v", err)
+	}
+	if config := result.(*testLiveEventConfig); config.Name != "second" || testDecodes.Load() != 1 {
+		t.Errorf("get value returned %+v after %d decodes, want the new live event decoded", config, testDecodes.Load())
+	}
+}
+
+func BenchmarkSatoriPersonalizerLiveEvents(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tags := make([]string, 1000)
+	for i := range tags {
+		tags[i] = strconv.Itoa(i)
+	}
+	value, _ := json.Marshal(map[string]any{"name": "large", "tags": tags})
+	nk := newTestNakamaModule(nil, []*runtime.LiveEvent{{Id: "large", Value: string(value)}})
+	p := NewSatoriPersonalizer(ctx)
+	system := newTestLiveEventSystem()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.GetValue(testRequestContext(b), &testLogger{}, nk, system, "user"); err != nil {
+			b.Fatalf("get value failed: %v", err)
+		}
+	}
+}
+
+func TestSatoriPersonalizerMaxCacheEntries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := NewSatoriPersonalizer(ctx, SatoriPersonalizerMaxCacheEntries(2))
+	nk := newTestNakamaModule([]*runtime.Flag{{Name: "Hiro-Economy", Value: `{"name":"flag"}`}}, nil)
+	system := newTestPersonalizedSystem(SystemTypeEconomy)
+	requests := []context.Context{testRequestContext(t), testRequestContext(t), testRequestContext(t)}
+
+	getValue := func(request int) int {
+		before, _ := nk.satori.calls()
+		if _, err := p.GetValue(requests[request], &testLogger{}, nk, system, "user"); err != nil {
+			t.Fatalf("get value failed: %v", err)
+		}
+		after, _ := nk.satori.calls()
+		return after - before
+	}
+
+	getValue(0)
+	getValue(1)
+	// Using the first entry makes the second the least recently used.
+	if calls := getValue(0); calls != 0 {
+		t.Errorf("cached request made %d flag requests, want 0", calls)
+	}
+	getValue(2)
+
+	if n := len(p.cache); n != 2 {
+		t.Errorf("%d entries cached, want 2", n)
+	}
+	if calls := getValue(0); calls != 0 {
+		t.Errorf("recently used request made %d flag requests, want 0", calls)
+	}
+	if calls := getValue(1); calls != 1 {
+		t.Errorf("evicted request made %d flag requests, want 1", calls)
+	}
+}
+
+func TestSatoriPersonalizerPrefetch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, liveEvents := range []bool{false, true} {
+		opts := []SatoriPersonalizerOption{}
+		if liveEvents {
+			opts = append(opts, SatoriPersonalizerPrefetchLiveEvents())
+		}
+		p := NewSatoriPersonalizer(ctx, opts...)
+		nk := newTestNakamaModule([]*runtime.Flag{{Name: "Hiro-Event-Leaderboards", Value: `{"name":"flag"}`}}, []*runtime.LiveEvent{{Id: "event", Value: `{"tags":["event"]}`}})
+
+		if err := p.Prefetch(ctx, &testLogger{}, nk, "user"); err != nil {
+			t.Fatalf("prefetch failed: %v", err)
+		}
+		if _, liveEventsCalls := nk.satori.calls(); (liveEventsCalls == 1) != liveEvents {
+			t.Errorf("prefetch with live events %v made %d live event requests", liveEvents, liveEventsCalls)
+		}
+
+		result, err := p.GetValue(testRequestContext(t), &testLogger{}, nk, newTestPersonalizedSystem(SystemTypeEventLeaderboards), "user")
+		if err != nil {
+			t.Fatalf("get value failed: %v", err)
+		}
+		if config := result.(*testPersonalizedConfig); config.Name != "flag" || !slices.Equal(config.Tags, []string{"base", "event"}) {
+			t.Errorf("get value returned %+v, want the flag and live event applied", config)
+		}
+		if flagsCalls, liveEventsCalls := nk.satori.calls(); flagsCalls != 1 || liveEventsCalls != 1 {
+			t.Errorf("made %d flag and %d live event requests, want 1 each", flagsCalls, liveEventsCalls)
+		}
+	}
+}
+
+func TestSatoriPersonalizerLiveEventOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	liveEvents := []*runtime.LiveEvent{
+		{Id: "high", Value: `{"hiro_priority":10,"name":"high"}`, ActiveStartTimeSec: 1},
+		{Id: "later", Value: `{"name":"later","tags":["later"]}`, ActiveStartTimeSec: 3},
+		{Id: "b", Value: `{"name":"b","tags":["b"]}`, ActiveStartTimeSec: 2},
+		{Id: "a", Value: `{"name":"a","tags":["a"]}`, ActiveStartTimeSec: 2},
+		{Id: "low", Value: `{"hiro_priority":-5,"name":"low","tags":["low"]}`, ActiveStartTimeSec: 4},
+	}
+
+	want := []string{
+		`{"name":"low","tags":["low"]}`,
+		`{"name":"a","tags":["a"]}`,
+		`{"name":"b","tags":["b"]}`,
+		`{"name":"later","tags":["later"]}`,
+		`{"name":"high"}`,
+	}
+	if values := satoriLiveEventValues(liveEvents); !slices.Equal(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+
+	// The highest priority wins whatever order the live events are listed in.
+	for _, order := range [][]int{{0, 1, 2, 3, 4}, {4, 3, 2, 1, 0}, {2, 0, 4, 1, 3}} {
+		ordered := make([]*runtime.LiveEvent, 0, len(order))
+		for _, i := range order {
+			ordered = append(ordered, liveEvents[i])
+		}
+		nk := newTestNakamaModule(nil, ordered)
+		result, err := NewSatoriPersonalizer(ctx).GetValue(testRequestContext(t), &testLogger{}, nk, newTestPersonalizedSystem(SystemTypeEventLeaderboards), "user")
+		if err != nil {
+			t.Fatalf("get value failed: %v", err)
+		}
+		if config := result.(*testPersonalizedConfig); config.Name != "high" || !slices.Equal(config.Tags, []string{"base", "low", "a", "b", "later"}) {
+			t.Errorf("get value with order %v returned %+v, want the high priority name and tags in priority order", order, config)
+		}
+	}
+}
+
+func TestSatoriPersonalizerActiveLiveEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now().Unix()
+	nk := newTestNakamaModule(nil, []*runtime.LiveEvent{
+		{Id: "active", ActiveStartTimeSec: now - 60, ActiveEndTimeSec: now + 60},
+		{Id: "open", ActiveStartTimeSec: now - 60},
+		{Id: "upcoming", ActiveStartTimeSec: now + 60, ActiveEndTimeSec: now + 120},
+		{Id: "ended", ActiveStartTimeSec: now - 120, ActiveEndTimeSec: now - 60},
+		nil,
+	})
+
+	p := NewSatoriPersonalizer(ctx)
+	request := testRequestContext(t)
+	for i := 0; i < 2; i++ {
+		liveEvents, err := p.ActiveLiveEvents(request, &testLogger{}, nk, "user")
+		if err != nil {
+			t.Fatalf("active live events failed: %v", err)
+		}
+		ids := make([]string, 0, len(liveEvents))
+		for _, liveEvent := range liveEvents {
+			ids = append(ids, liveEvent.Id)
+		}
+		if !slices.Equal(ids, []string{"active", "open"}) {
+			t.Errorf("active live events = %v, want [active open]", ids)
+		}
+	}
+
+	// The live events are fetched once and kept for the rest of the request once GetValue has cached the flags.
+	if _, err := p.GetValue(request, &testLogger{}, nk, newTestPersonalizedSystem(SystemTypeEventLeaderboards), "user"); err != nil {
+		t.Fatalf("get value failed: %v", err)
+	}
+	_, before := nk.satori.calls()
+	if _, err := p.ActiveLiveEvents(request, &testLogger{}, nk, "user"); err != nil {
+		t.Fatalf("active live events failed: %v", err)
+	}
+	if _, after := nk.satori.calls(); after != before {
+		t.Errorf("active live events made %d live event requests after they were cached, want 0", after-before)
+	}
+
+	disabled := NewSatoriPersonalizer(ctx, SatoriPersonalizerDisableLiveEvents())
+	if liveEvents, err := disabled.ActiveLiveEvents(testRequestContext(t), &testLogger{}, nk, "user"); err != nil || liveEvents != nil {
+		t.Errorf("active live events with live events disabled = %v, %v, want none", liveEvents, err)
+	}
+}
+
+func TestSatoriPersonalizerLiveEventValidation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	system := newTestPersonalizedSystem(SystemTypeEventLeaderboards)
+	tests := []struct {
+		name  string
+		value string
+		want  *testPersonalizedConfig
+	}{
+		{name: "valid", value: `{"mode":"weekly"}`, want: &testPersonalizedConfig{Name: "base", Mode: "weekly", Scale: 1, Tags: []string{"base"}}},
+		{name: "clamped", value: `{"scale":50}`, want: &testPersonalizedConfig{Name: "base", Mode: "daily", Scale: 10, Tags: []string{"base"}}},
+		{name: "out of range enum", value: `{"mode":"hourly"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nk := newTestNakamaModule(nil, []*runtime.LiveEvent{{Id: "event", Value: tt.value}})
+			result, err := NewSatoriPersonalizer(ctx).GetValue(testRequestContext(t), &testLogger{}, nk, system, "user")
+			if err != nil {
+				t.Fatalf("get value failed: %v", err)
+			}
+			if tt.want == nil {
+				if result != nil {
+					t.Errorf("get value returned %+v, want the base config used", result)
+				}
+				return
+			}
+			if config := result.(*testPersonalizedConfig); config.Name != tt.want.Name || config.Mode != tt.want.Mode || config.Scale != tt.want.Scale || !slices.Equal(config.Tags, tt.want.Tags) {
+				t.Errorf("get value returned %+v, want %+v", config, tt.want)
+			}
+		})
+	}
+}
+
+func TestSatoriPersonalizerCacheTTL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const ttl = 20 * time.Millisecond
+	p := NewSatoriPersonalizer(ctx, SatoriPersonalizerCacheTTL(ttl))
+	nk := newTestNakamaModule([]*runtime.Flag{{Name: "Hiro-Economy", Value: `{"name":"first"}`}}, nil)
+	system := newTestPersonalizedSystem(SystemTypeEconomy)
+	request := testRequestContext(t)
+
+	getName := func(ctx context.Context) string {
+		result, err := p.GetValue(ctx, &testLogger{}, nk, system, "user")
+		if err != nil {
+			t.Fatalf("get value failed: %v", err)
+		}
+		return result.(*testPersonalizedConfig).Name
+	}
+
+	if name := getName(request); name != "first" {
+		t.Fatalf("name = %q, want first", name)
+	}
+	nk.satori.setFlags([]*runtime.Flag{{Name: "Hiro-Economy", Value: `{"name":"second"}`}})
+	if name := getName(request); name != "first" {
+		t.Errorf("name = %q before the TTL, want the cached value first", name)
+	}
+
+	// An expired entry is a miss, so the flags are fetched again within the same context.
+	time.Sleep(2 * ttl)
+	if name := getName(request); name != "second" {
+		t.Errorf("name = %q after the TTL, want the refreshed value second", name)
+	}
+	if flagsCalls, _ := nk.satori.calls(); flagsCalls != 2 {
+		t.Errorf("made %d flag requests, want 2", flagsCalls)
+	}
+
+	// Prefetched values older than the TTL are a miss too.
+	if err := p.Prefetch(ctx, &testLogger{}, nk, "user"); err != nil {
+		t.Fatalf("prefetch failed: %v", err)
+	}
+	nk.satori.setFlags([]*runtime.Flag{{Name: "Hiro-Economy", Value: `{"name":"third"}`}})
+	time.Sleep(2 * ttl)
+	if name := getName(testRequestContext(t)); name != "third" {
+		t.Errorf("name = %q after the prefetch expired, want the refreshed value third", name)
+	}
+}
@@ -354,9 +354,7 @@ func (p *StoragePersonalizer) GetValue(ctx context.Context, logger runtime.Logge
 	}
 
 	config := system.GetConfig()
-	decoder := json.NewDecoder(strings.NewReader(cached.object.Value))
-	decoder.DisallowUnknownFields()
-	if err := decoder.Decode(config); err != nil {
+	if err := PersonalizerDecode(cached.object.Value, config); err != nil {
 		logger.WithField("userID", userID).WithField("error", err.Error()).Error("error merging storage value")
 		return nil, err
 	}
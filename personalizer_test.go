@@ -0,0 +1,167 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"slices"
+	"testing"
+)
+
+type testMergeItem struct {
+	Id    string `json:"id,omitempty"`
+	Price int64  `json:"price,omitempty"`
+}
+
+type testMergeConfig struct {
+	Replaced []string         `json:"replaced,omitempty"`
+	Appended []string         `json:"appended,omitempty" hiro:"merge=append"`
+	Merged   []*testMergeItem `json:"merged,omitempty" hiro:"merge=key:id"`
+}
+
+func newTestMergeConfig() *testMergeConfig {
+	return &testMergeConfig{
+		Replaced: []string{"a", "b"},
+		Appended: []string{"a", "b"},
+		Merged:   []*testMergeItem{{Id: "gems", Price: 100}, {Id: "coins", Price: 10}},
+	}
+}
+
+func TestPersonalizerDecodeAppend(t *testing.T) {
+	config := newTestMergeConfig()
+	if err := PersonalizerDecode(`{"appended":["c"],"replaced":["c"]}`, config); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	if !slices.Equal(config.Appended, []string{"a", "b", "c"}) {
+		t.Errorf("appended = %v, want [a b c]", config.Appended)
+	}
+	if !slices.Equal(config.Replaced, []string{"c"}) {
+		t.Errorf("replaced = %v, want [c]", config.Replaced)
+	}
+}
+
+func TestPersonalizerDecodeMergeByKey(t *testing.T) {
+	config := newTestMergeConfig()
+	gems := config.Merged[0]
+	if err := PersonalizerDecode(`{"merged":[{"id":"gems","price":50},{"id":"energy","price":5}]}`, config); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	want := []testMergeItem{{Id: "gems", Price: 50}, {Id: "coins", Price: 10}, {Id: "energy", Price: 5}}
+	if len(config.Merged) != len(want) {
+		t.Fatalf("merged has %d items, want %d", len(config.Merged), len(want))
+	}
+	for i, item := range config.Merged {
+		if *item != want[i] {
+			t.Errorf("merged[%d] = %+v, want %+v", i, *item, want[i])
+		}
+	}
+	if gems.Price != 100 {
+		t.Errorf("base item was modified, price = %d, want 100", gems.Price)
+	}
+}
+
+func TestPersonalizerDecodeOmittedAndNull(t *testing.T) {
+	config := newTestMergeConfig()
+	if err := PersonalizerDecode(`{"replaced":["c"]}`, config); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !slices.Equal(config.Appended, []string{"a", "b"}) || len(config.Merged) != 2 {
+		t.Errorf("omitted fields changed: appended = %v, merged has %d items", config.Appended, len(config.Merged))
+	}
+
+	if err := PersonalizerDecode(`{"appended":[],"merged":null}`, config); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !slices.Equal(config.Appended, []string{"a", "b"}) {
+		t.Errorf("appended = %v, an empty list should append nothing", config.Appended)
+	}
+	if config.Merged != nil {
+		t.Errorf("merged = %v, null should clear it", config.Merged)
+	}
+}
+
+func TestPersonalizerDecodeErrorRestores(t *testing.T) {
+	config := newTestMergeConfig()
+	if err := PersonalizerDecode(`{"appended":["c"],"unknown":true}`, config); err == nil {
+		t.Fatal("decode of an unknown field succeeded")
+	}
+	if !slices.Equal(config.Appended, []string{"a", "b"}) {
+		t.Errorf("appended = %v, want the previous value restored", config.Appended)
+	}
+}
+
+func TestPersonalizerLiveEventPriority(t *testing.T) {
+	tests := []struct {
+		value     string
+		priority  int
+		remaining string
+	}{
+		{value: `{"name":"a"}`, priority: 0, remaining: `{"name":"a"}`},
+		{value: `{"hiro_priority":10,"name":"a"}`, priority: 10, remaining: `{"name":"a"}`},
+		{value: `{"hiro_priority":-1}`, priority: -1, remaining: `{}`},
+		{value: `{"hiro_priority":"high","name":"a"}`, priority: 0, remaining: `{"hiro_priority":"high","name":"a"}`},
+		{value: `not json "hiro_priority"`, priority: 0, remaining: `not json "hiro_priority"`},
+	}
+
+	for _, tt := range tests {
+		priority, remaining := PersonalizerLiveEventPriority(tt.value)
+		if priority != tt.priority || remaining != tt.remaining {
+			t.Errorf("priority of %s = %d, %s, want %d, %s", tt.value, priority, remaining, tt.priority, tt.remaining)
+		}
+	}
+}
+
+type testValidateLevel struct {
+	Size int `json:"size,omitempty" hirovalidate:"max=3,policy=clamp"`
+}
+
+type testValidateConfig struct {
+	Policy string                       `json:"policy,omitempty" hirovalidate:"oneof=top|shared,policy=default,default=top"`
+	Tier   string                       `json:"tier,omitempty" hirovalidate:"oneof=gold|silver"`
+	Rate   float64                      `json:"rate,omitempty" hirovalidate:"min=0,max=1,policy=clamp"`
+	Count  int                          `json:"count,omitempty" hirovalidate:"min=1,max=10,policy=default,default=5"`
+	Levels map[string]testValidateLevel `json:"levels,omitempty"`
+}
+
+func TestPersonalizerValidate(t *testing.T) {
+	config := &testValidateConfig{Policy: "shared", Tier: "gold", Rate: 0.5, Count: 3, Levels: map[string]testValidateLevel{"a": {Size: 2}}}
+	if violations, reject := PersonalizerValidate(config); len(violations) != 0 || reject {
+		t.Fatalf("valid config has %d violations, reject %v", len(violations), reject)
+	}
+
+	config = &testValidateConfig{Policy: "random", Rate: 2, Count: 20, Levels: map[string]testValidateLevel{"a": {Size: 5}}}
+	violations, reject := PersonalizerValidate(config)
+	if reject {
+		t.Error("config with only default and clamp violations was rejected")
+	}
+	if len(violations) != 4 {
+		t.Errorf("%d violations, want 4", len(violations))
+	}
+	if config.Policy != "top" || config.Rate != 1 || config.Count != 5 || config.Levels["a"].Size != 3 {
+		t.Errorf("config = %+v, want the violations fixed in place", config)
+	}
+
+	config = &testValidateConfig{Rate: -1}
+	if _, reject = PersonalizerValidate(config); reject || config.Rate != 0 {
+		t.Errorf("rate = %v, want it clamped to 0", config.Rate)
+	}
+
+	config = &testValidateConfig{Tier: "bronze", Rate: 0.5}
+	violations, reject = PersonalizerValidate(config)
+	if !reject || len(violations) != 1 || violations[0].Path != "tier" || violations[0].Policy != PersonalizerValidatePolicyReject {
+		t.Errorf("violations = %+v, reject %v, want the tier rejected", violations, reject)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var ErrPrerequisiteNotMet = runtime.NewError("prerequisite not met", 9) // FAILED_PRECONDITION
+
+// Prerequisite is a condition across systems which gates an action, such as purchasing a store item. Like progression
+// preconditions, the direct conditions are combined with the nested prerequisite by the operator.
+type Prerequisite struct {
+	Direct   *PrerequisiteDirect              `json:"direct,omitempty"`
+	Operator ProgressionPreconditionsOperator `json:"operator,omitempty"`
+	Nested   *Prerequisite                    `json:"nested,omitempty"`
+}
+
+// PrerequisiteDirect are conditions which must all be met by the user.
+type PrerequisiteDirect struct {
+	Achievements []string         `json:"achievements,omitempty"`
+	Progressions []string         `json:"progressions,omitempty"`
+	Tutorials    []string         `json:"tutorials,omitempty"`
+	StatsMin     map[string]int64 `json:"stats_min,omitempty"`
+	StatsMax     map[string]int64 `json:"stats_max,omitempty"`
+}
+
+// PrerequisiteState is the user's state across systems which prerequisites are evaluated against.
+type PrerequisiteState interface {
+	AchievementCompleted(achievementID string) bool
+	ProgressionUnlocked(progressionID string) bool
+	TutorialCompleted(tutorialID string) bool
+	StatValue(name string) (value int64, found bool)
+}
+
+// PrerequisiteError is returned when a prerequisite is not met, and describes the failing condition. It wraps
+// ErrPrerequisiteNotMet.
+type PrerequisiteError struct {
+	// The condition which failed, for example "tutorial onboarding" or "stat level >= 5".
+	Condition string
+}
+
+func (e *PrerequisiteError) Error() string {
+	return ErrPrerequisiteNotMet.Error() + ": " + e.Condition
+}
+
+func (e *PrerequisiteError) Unwrap() error {
+	return ErrPrerequisiteNotMet
+}
+
+// Evaluate checks the prerequisite against the user's state. It returns nil if the prerequisite is met, otherwise a
+// *PrerequisiteError with the failing condition. A nil prerequisite is always met.
+func (p *Prerequisite) Evaluate(state PrerequisiteState) error {
+	if p == nil {
+		return nil
+	}
+
+	directErr := p.Direct.evaluate(state)
+	if p.Nested == nil {
+		return directErr
+	}
+	nestedErr := p.Nested.Evaluate(state)
+
+	switch p.Operator {
+	case ProgressionPreconditionsOperator_PROGRESSION_PRECONDITIONS_OPERATOR_OR:
+		if directErr == nil || nestedErr == nil {
+			return nil
+		}
+		return directErr
+	case ProgressionPreconditionsOperator_PROGRESSION_PRECONDITIONS_OPERATOR_XOR:
+		switch {
+		case directErr == nil && nestedErr == nil:
+			return &PrerequisiteError{Condition: "exactly one of direct or nested"}
+		case directErr != nil && nestedErr != nil:
+			return directErr
+		}
+		return nil
+	case ProgressionPreconditionsOperator_PROGRESSION_PRECONDITIONS_OPERATOR_NOT:
+		if directErr != nil {
+			return directErr
+		}
+		if nestedErr == nil {
+			return &PrerequisiteError{Condition: "not nested"}
+		}
+		return nil
+	default:
+		if directErr != nil {
+			return directErr
+		}
+		return nestedErr
+	}
+}
+
+func (d *PrerequisiteDirect) evaluate(state PrerequisiteState) error {
+	if d == nil {
+		return nil
+	}
+
+	for _, id := range d.Achievements {
+		if !state.AchievementCompleted(id) {
+			return &PrerequisiteError{Condition: "achievement " + id}
+		}
+	}
+	for _, id := range d.Progressions {
+		if !state.ProgressionUnlocked(id) {
+			return &PrerequisiteError{Condition: "progression " + id}
+		}
+	}
+	for _, id := range d.Tutorials {
+		if !state.TutorialCompleted(id) {
+			return &PrerequisiteError{Condition: "tutorial " + id}
+		}
+	}
+	// Stats are checked in a stable order so the same failing condition is reported each time.
+	for _, name := range slices.Sorted(maps.Keys(d.StatsMin)) {
+		if value, _ := state.StatValue(name); value < d.StatsMin[name] {
+			return &PrerequisiteError{Condition: "stat " + name + " >= " + strconv.FormatInt(d.StatsMin[name], 10)}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(d.StatsMax)) {
+		if value, _ := state.StatValue(name); value > d.StatsMax[name] {
+			return &PrerequisiteError{Condition: "stat " + name + " <= " + strconv.FormatInt(d.StatsMax[name], 10)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// testPrerequisiteState is a PrerequisiteState with fixed completed tutorials and stat values.
+type testPrerequisiteState struct {
+	tutorials []string
+	stats     map[string]int64
+}
+
+func (s *testPrerequisiteState) AchievementCompleted(string) bool { return false }
+func (s *testPrerequisiteState) ProgressionUnlocked(string) bool  { return false }
+func (s *testPrerequisiteState) TutorialCompleted(tutorialID string) bool {
+	return slices.Contains(s.tutorials, tutorialID)
+}
+func (s *testPrerequisiteState) StatValue(name string) (int64, bool) {
+	value, found := s.stats[name]
+	return value, found
+}
+
+func TestPrerequisiteEvaluate(t *testing.T) {
+	prerequisite := &Prerequisite{Direct: &PrerequisiteDirect{Tutorials: []string{"onboarding"}, StatsMin: map[string]int64{"level": 5}}}
+	state := &testPrerequisiteState{stats: map[string]int64{"level": 7}}
+
+	err := prerequisite.Evaluate(state)
+	if !errors.Is(err, ErrPrerequisiteNotMet) {
+		t.Fatalf("evaluate before the tutorial returned %v, want %v", err, ErrPrerequisiteNotMet)
+	}
+	var prerequisiteErr *PrerequisiteError
+	if !errors.As(err, &prerequisiteErr) || prerequisiteErr.Condition != "tutorial onboarding" {
+		t.Fatalf("failing condition = %v, want tutorial onboarding", err)
+	}
+
+	state.tutorials = []string{"onboarding"}
+	if err = prerequisite.Evaluate(state); err != nil {
+		t.Fatalf("evaluate after the tutorial returned %v, want nil", err)
+	}
+
+	state.stats["level"] = 4
+	if err = prerequisite.Evaluate(state); !errors.As(err, &prerequisiteErr) || prerequisiteErr.Condition != "stat level >= 5" {
+		t.Fatalf("evaluate below the stat minimum returned %v, want stat level >= 5", err)
+	}
+
+	var unset *Prerequisite
+	if err = unset.Evaluate(state); err != nil {
+		t.Fatalf("evaluate a nil prerequisite returned %v, want nil", err)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var ErrProfileBusy = runtime.NewError("profile busy", 10) // ABORTED
+
+// The ProfileLocker serializes whole-profile operations, such as exports, reconciliation, and migrations, with the
+// normal mutations made to the same user so they observe a consistent snapshot.
+//
+// Whole-profile operations acquire the exclusive lock, and normal mutations acquire the shared lock. Both return
+// ErrProfileBusy if the lock cannot be acquired before the timeout elapses or the context is cancelled.
+//
+// ProfileLocker implementations must safely handle concurrent calls.
+type ProfileLocker interface {
+	// Lock acquires the exclusive lock for the user. The returned function must be called to release it.
+	Lock(ctx context.Context, userID string) (unlock func(), err error)
+
+	// RLock acquires the shared lock for the user. The returned function must be called to release it.
+	RLock(ctx context.Context, userID string) (unlock func(), err error)
+}
+
+var _ ProfileLocker = (*LocalProfileLocker)(nil)
+
+// LocalProfileLocker is an in-process ProfileLocker. It only serializes operations handled by the same game server.
+type LocalProfileLocker struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	locks   map[string]*localProfileLock
+}
+
+type localProfileLock struct {
+	readers int
+	writer  bool
+	waiters int
+	// Closed and replaced each time the lock is released to wake any waiters.
+	released chan struct{}
+}
+
+// NewLocalProfileLocker creates an in-process ProfileLocker. The timeout is how long to wait to acquire a lock before
+// ErrProfileBusy is returned, a zero timeout fails immediately on contention.
+func NewLocalProfileLocker(timeout time.Duration) *LocalProfileLocker {
+	return &LocalProfileLocker{
+		timeout: timeout,
+		locks:   make(map[string]*localProfileLock),
+	}
+}
+
+func (l *LocalProfileLocker) Lock(ctx context.Context, userID string) (func(), error) {
+	return l.acquire(ctx, userID, true)
+}
+
+func (l *LocalProfileLocker) RLock(ctx context.Context, userID string) (func(), error) {
+	return l.acquire(ctx, userID, false)
+}
+
+func (l *LocalProfileLocker) acquire(ctx context.Context, userID string, exclusive bool) (func(), error) {
+	var timer <-chan time.Time
+	if l.timeout > 0 {
+		t := time.NewTimer(l.timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	l.mu.Lock()
+	lock, found := l.locks[userID]
+	if !found {
+		lock = &localProfileLock{released: make(chan struct{})}
+		l.locks[userID] = lock
+	}
+	for lock.writer || (exclusive && lock.readers > 0) {
+		if l.timeout <= 0 {
+			l.release(userID, lock)
+			l.mu.Unlock()
+			return nil, ErrProfileBusy
+		}
+
+		released := lock.released
+		lock.waiters++
+		l.mu.Unlock()
+
+		var err error
+		select {
+		case <-released:
+		case <-timer:
+			err = ErrProfileBusy
+		case <-ctx.Done():
+			err = ErrProfileBusy
+		}
+
+		l.mu.Lock()
+		lock.waiters--
+		if err != nil {
+			l.release(userID, lock)
+			l.mu.Unlock()
+			return nil, err
+		}
+	}
+	if exclusive {
+		lock.writer = true
+	} else {
+		lock.readers++
+	}
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			if exclusive {
+				lock.writer = false
+			} else {
+				lock.readers--
+			}
+			close(lock.released)
+			lock.released = make(chan struct{})
+			l.release(userID, lock)
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// release removes the lock entry for the user once it is no longer held or waited on. Must be called with mu held.
+func (l *LocalProfileLocker) release(userID string, lock *localProfileLock) {
+	if !lock.writer && lock.readers == 0 && lock.waiters == 0 {
+		delete(l.locks, userID)
+	}
+}
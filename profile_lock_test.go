@@ -0,0 +1,90 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLocalProfileLockerSerializesExport(t *testing.T) {
+	ctx := testRequestContext(t)
+	locker := NewLocalProfileLocker(time.Second)
+
+	unlock, err := locker.Lock(ctx, "user")
+	if err != nil {
+		t.Fatalf("lock export: %v", err)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		unlockMutation, err := locker.RLock(ctx, "user")
+		if err == nil {
+			unlockMutation()
+		}
+		acquired <- err
+	}()
+
+	select {
+	case err := <-acquired:
+		t.Fatalf("mutation acquired the lock during the export: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("mutation after the export: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("mutation did not acquire the lock after the export released it")
+	}
+
+	// Another user's lock is independent.
+	unlock, err = locker.Lock(ctx, "user")
+	if err != nil {
+		t.Fatalf("lock export again: %v", err)
+	}
+	defer unlock()
+	unlockOther, err := locker.Lock(ctx, "other")
+	if err != nil {
+		t.Fatalf("lock another user: %v", err)
+	}
+	unlockOther()
+}
+
+func TestLocalProfileLockerTimeout(t *testing.T) {
+	ctx := testRequestContext(t)
+	locker := NewLocalProfileLocker(20 * time.Millisecond)
+
+	unlock, err := locker.Lock(ctx, "user")
+	if err != nil {
+		t.Fatalf("lock export: %v", err)
+	}
+	defer unlock()
+
+	start := time.Now()
+	if _, err = locker.Lock(ctx, "user"); !errors.Is(err, ErrProfileBusy) {
+		t.Fatalf("concurrent lock error = %v, want %v", err, ErrProfileBusy)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("concurrent lock failed after %v, before the timeout", elapsed)
+	}
+	if _, err = locker.RLock(ctx, "user"); !errors.Is(err, ErrProfileBusy) {
+		t.Fatalf("concurrent shared lock error = %v, want %v", err, ErrProfileBusy)
+	}
+}
@@ -16,6 +16,7 @@ package hiro
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 )
@@ -27,11 +28,23 @@ var (
 	ErrProgressionNoCost               = runtime.NewError("progression no cost associated", 3)        // INVALID_ARGUMENT
 	ErrProgressionNoCount              = runtime.NewError("progression no count associated", 3)       // INVALID_ARGUMENT
 	ErrProgressionAlreadyUnlocked      = runtime.NewError("progression already unlocked", 3)          // INVALID_ARGUMENT
+	ErrProgressionTrackNotFound        = runtime.NewError("progression track not found", 3)           // INVALID_ARGUMENT
+	ErrProgressionTrackPremiumRequired = runtime.NewError("progression track premium required", 3)    // INVALID_ARGUMENT
+	ErrProgressionTrackAlreadyClaimed  = runtime.NewError("progression track already claimed", 3)     // INVALID_ARGUMENT
+	ErrProgressionTrackClaimExpired    = runtime.NewError("progression track claim window ended", 3)  // INVALID_ARGUMENT
+	ErrProgressionLayoutInvalid        = runtime.NewError("progression layout invalid", 3)            // INVALID_ARGUMENT
+	ErrProgressionLayoutTooLarge       = runtime.NewError("progression layout too large", 3)          // INVALID_ARGUMENT
 )
 
+// ProgressionLayoutMaxBytesDefault is the size limit of each progression's layout when the config does not set one.
+const ProgressionLayoutMaxBytesDefault = 4096
+
 // ProgressionConfig is the data definition for a ProgressionSystem type.
 type ProgressionConfig struct {
 	Progressions map[string]*ProgressionConfigProgression `json:"progressions,omitempty"`
+	Tracks       map[string]*ProgressionConfigTrack       `json:"tracks,omitempty"`
+	// The size limit in bytes of each progression's layout, zero uses ProgressionLayoutMaxBytesDefault.
+	LayoutMaxBytes int `json:"layout_max_bytes,omitempty"`
 }
 
 type ProgressionConfigProgression struct {
@@ -41,15 +54,141 @@ type ProgressionConfigProgression struct {
 	AdditionalProperties map[string]string              `json:"additional_properties,omitempty"`
 	Preconditions        *ProgressionPreconditionsBlock `json:"preconditions,omitempty"`
 	ResetSchedule        string                         `json:"reset_schedule,omitempty"`
+	// Arbitrary client layout metadata for the node, such as its position, icon, and group. It's returned verbatim
+	// and is not part of the progression state, so layout-only changes do not produce deltas.
+	Layout json.RawMessage `json:"layout,omitempty"`
+}
+
+// ValidateLayouts checks the layout of every progression, including those in tracks, is a JSON object within the
+// configured size limit. It should be called on the base config and again after personalization is applied.
+func (c *ProgressionConfig) ValidateLayouts() error {
+	if c == nil {
+		return nil
+	}
+	maxBytes := c.LayoutMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = ProgressionLayoutMaxBytesDefault
+	}
+
+	validate := func(layout json.RawMessage) error {
+		if len(layout) == 0 {
+			return nil
+		}
+		if len(layout) > maxBytes {
+			return ErrProgressionLayoutTooLarge
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(layout, &fields); err != nil {
+			return ErrProgressionLayoutInvalid
+		}
+		return nil
+	}
+
+	for _, progression := range c.Progressions {
+		if progression == nil {
+			continue
+		}
+		if err := validate(progression.Layout); err != nil {
+			return err
+		}
+	}
+	for _, track := range c.Tracks {
+		if track == nil {
+			continue
+		}
+		for _, node := range track.Progressions {
+			if node == nil {
+				continue
+			}
+			if err := validate(node.Layout); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ProgressionConfigTrack is a periodic (seasonal) progression track which is layered on the permanent progressions.
+// Its progressions are in their own namespace and reset at the end of each season.
+type ProgressionConfigTrack struct {
+	Name                 string                                 `json:"name,omitempty"`
+	Description          string                                 `json:"description,omitempty"`
+	ResetCronexpr        string                                 `json:"reset_cronexpr,omitempty"`
+	GraceDurationSec     int64                                  `json:"grace_duration_sec,omitempty"`
+	Premium              *ProgressionConfigTrackPremium         `json:"premium,omitempty"`
+	Progressions         map[string]*ProgressionConfigTrackNode `json:"progressions,omitempty"`
+	AdditionalProperties map[string]string                      `json:"additional_properties,omitempty"`
+}
+
+// ProgressionConfigTrackPremium defines how a user unlocks the premium rewards of a track for the current season.
+type ProgressionConfigTrackPremium struct {
+	// A store item which unlocks premium rewards when purchased.
+	StoreItemId string `json:"store_item_id,omitempty"`
+	// An inventory item which unlocks premium rewards while it's owned.
+	ItemId string `json:"item_id,omitempty"`
+}
+
+type ProgressionConfigTrackNode struct {
+	ProgressionConfigProgression
+	Reward        *EconomyConfigReward `json:"reward,omitempty"`
+	PremiumReward *EconomyConfigReward `json:"premium_reward,omitempty"`
+}
+
+// ProgressionTrack is a user's state in the current season of a progression track.
+type ProgressionTrack struct {
+	Id             string                  `json:"id,omitempty"`
+	StartTimeSec   int64                   `json:"start_time_sec,omitempty"`
+	EndTimeSec     int64                   `json:"end_time_sec,omitempty"`
+	Premium        bool                    `json:"premium,omitempty"`
+	Progressions   map[string]*Progression `json:"progressions,omitempty"`
+	Claimed        map[string]bool         `json:"claimed,omitempty"`
+	PremiumClaimed map[string]bool         `json:"premium_claimed,omitempty"`
+	// The season which has just ended, while its rewards can still be claimed during the grace window.
+	Previous *ProgressionTrackSeason `json:"previous,omitempty"`
+	// Archived seasons the user took part in, from most recent.
+	History []*ProgressionTrackSeason `json:"history,omitempty"`
+}
+
+// ProgressionTrackSeason is a user's archived state from a past season of a progression track.
+type ProgressionTrackSeason struct {
+	StartTimeSec    int64            `json:"start_time_sec,omitempty"`
+	EndTimeSec      int64            `json:"end_time_sec,omitempty"`
+	GraceEndTimeSec int64            `json:"grace_end_time_sec,omitempty"`
+	Premium         bool             `json:"premium,omitempty"`
+	Unlocked        []string         `json:"unlocked,omitempty"`
+	Counts          map[string]int64 `json:"counts,omitempty"`
+	Claimed         map[string]bool  `json:"claimed,omitempty"`
+	PremiumClaimed  map[string]bool  `json:"premium_claimed,omitempty"`
 }
 
 // A ProgressionSystem is a gameplay system which represents a sequence of progression steps.
 type ProgressionSystem interface {
 	System
+	// ResolveCost resolves the cost of EconomyCostOperationProgressionPath operations for cost previews.
+	EconomyCostResolver
 
 	// Get returns all or an optionally-filtered set of progressions for the given user.
 	Get(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, lastKnownProgressions map[string]*Progression) (progressions map[string]*Progression, deltas map[string]*ProgressionDelta, err error)
 
+	// GetLayouts returns the layout of each progression, personalized for the user, keyed by progression ID. Layouts
+	// are returned separately from the progressions so a client can cache them independently, or omit them entirely
+	// from a config response with a projection.
+	GetLayouts(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (layouts map[string]json.RawMessage, err error)
+
+	// GetWithTracks returns the same progressions as Get, together with the user's state in every progression track.
+	// Tracks whose season has ended are archived to history and reset first.
+	GetWithTracks(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, lastKnownProgressions map[string]*Progression) (progressions map[string]*Progression, deltas map[string]*ProgressionDelta, tracks map[string]*ProgressionTrack, err error)
+
+	// TrackUpdate updates a progression within the current season of a progression track.
+	TrackUpdate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, trackID, progressionID string, counts map[string]int64) (track *ProgressionTrack, err error)
+
+	// TrackClaim claims the rewards of an unlocked progression in a track, including its premium reward if the user
+	// has unlocked premium. Set previous to claim for the season which just ended, while within its grace window.
+	TrackClaim(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, trackID, progressionID string, previous bool) (track *ProgressionTrack, reward *Reward, err error)
+
+	// SetOnTrackReward sets a custom reward function which will run after a progression track reward is rolled.
+	SetOnTrackReward(fn OnReward[*ProgressionConfigTrackNode])
+
 	// Purchase permanently unlocks a specified progression, if that progression supports this operation.
 	Purchase(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, progressionID string) (progressions map[string]*Progression, err error)
 
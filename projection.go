@@ -0,0 +1,116 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ProjectionWildcard matches every key of an object, or every element of an array, at one level of a projection path.
+const ProjectionWildcard = "*"
+
+// Project returns only the sections of a value, such as a personalized system config, selected by the projection
+// paths. Each path is a dot-separated list of JSON field names, for example "store_items.*.cost" selects the cost of
+// every store item. An empty projection returns the whole value.
+//
+// Projections are applied to the JSON form of the value after personalization, to reduce the size of responses.
+func Project(value any, projection []string) (json.RawMessage, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, ErrPayloadEncode
+	}
+	if len(projection) == 0 {
+		return data, nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, ErrPayloadDecode
+	}
+
+	var projected any
+	for _, path := range projection {
+		if path == "" {
+			continue
+		}
+		projected, _ = projectPath(decoded, projected, strings.Split(path, "."))
+	}
+	if projected == nil {
+		projected = map[string]any{}
+	}
+
+	if data, err = json.Marshal(projected); err != nil {
+		return nil, ErrPayloadEncode
+	}
+	return data, nil
+}
+
+// projectPath copies the parts of src selected by path into dst, and returns the updated dst and whether the path
+// selected anything. Keys and elements are only added to dst for paths which select a value, so a path which doesn't
+// match, such as one which continues past a scalar value, leaves dst unchanged.
+func projectPath(src, dst any, path []string) (any, bool) {
+	if len(path) == 0 {
+		return src, true
+	}
+
+	switch src := src.(type) {
+	case map[string]any:
+		out, ok := dst.(map[string]any)
+		if !ok {
+			out = make(map[string]any)
+		}
+		var selected bool
+		if path[0] == ProjectionWildcard {
+			for key, value := range src {
+				if projected, found := projectPath(value, out[key], path[1:]); found {
+					out[key] = projected
+					selected = true
+				}
+			}
+		} else if value, found := src[path[0]]; found {
+			if projected, found := projectPath(value, out[path[0]], path[1:]); found {
+				out[path[0]] = projected
+				selected = true
+			}
+		}
+		if !selected {
+			return dst, false
+		}
+		return out, true
+	case []any:
+		if path[0] != ProjectionWildcard {
+			return dst, false
+		}
+		out, ok := dst.([]any)
+		if !ok || len(out) != len(src) {
+			out = make([]any, len(src))
+		}
+		var selected bool
+		for i, value := range src {
+			if projected, found := projectPath(value, out[i], path[1:]); found {
+				out[i] = projected
+				selected = true
+			}
+		}
+		if !selected {
+			return dst, false
+		}
+		return out, true
+	default:
+		// Paths which continue past a scalar value select nothing.
+		return dst, false
+	}
+}
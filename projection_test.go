@@ -0,0 +1,116 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"testing"
+)
+
+func newTestProjectionEconomyConfig() *EconomyConfig {
+	return &EconomyConfig{
+		Currencies: map[string]*EconomyConfigCurrency{
+			"gold": {Precision: 2, Rounding: "floor"},
+		},
+		StoreItems: map[string]*EconomyConfigStoreItem{
+			"gems": {
+				Category: "currency",
+				Name:     "Gems",
+				Cost:     &EconomyConfigStoreItemCost{Currencies: map[string]int64{"gold": 100}},
+			},
+			"starter": {
+				Category: "bundle",
+				Name:     "Starter Pack",
+				Cost:     &EconomyConfigStoreItemCost{Sku: "com.example.starter"},
+			},
+		},
+		AllowFakeReceipts: true,
+	}
+}
+
+func TestProjectEconomyConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		projection []string
+		want       string
+	}{
+		{
+			name:       "wildcard",
+			projection: []string{"store_items.*.name"},
+			want:       `{"store_items":{"gems":{"name":"Gems"},"starter":{"name":"Starter Pack"}}}`,
+		},
+		{
+			name:       "multiple paths",
+			projection: []string{"store_items.gems.cost", "currencies.*.precision"},
+			want:       `{"currencies":{"gold":{"precision":2}},"store_items":{"gems":{"cost":{"currencies":{"gold":100}}}}}`,
+		},
+		{
+			name:       "missing keys are omitted",
+			projection: []string{"store_items.*.cost.currencies"},
+			want:       `{"store_items":{"gems":{"cost":{"currencies":{"gold":100}}}}}`,
+		},
+		{
+			name:       "paths past a scalar are omitted",
+			projection: []string{"store_items.*.name.first", "allow_fake_receipts.value", "currencies.gold.rounding"},
+			want:       `{"currencies":{"gold":{"rounding":"floor"}}}`,
+		},
+		{
+			name:       "no match",
+			projection: []string{"placements.*", "store_items.unknown"},
+			want:       `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Project(newTestProjectionEconomyConfig(), tt.projection)
+			if err != nil {
+				t.Fatalf("project failed: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("projected %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectArray(t *testing.T) {
+	value := map[string]any{"items": []any{map[string]any{"id": "a", "count": 1}, map[string]any{"id": "b"}, "c"}}
+
+	data, err := Project(value, []string{"items.*.id", "items.0"})
+	if err != nil {
+		t.Fatalf("project failed: %v", err)
+	}
+	if want := `{"items":[{"id":"a"},{"id":"b"},null]}`; string(data) != want {
+		t.Errorf("projected %s, want %s", data, want)
+	}
+
+	data, err = Project(value, []string{"items.*.count.value"})
+	if err != nil {
+		t.Fatalf("project failed: %v", err)
+	}
+	if want := `{}`; string(data) != want {
+		t.Errorf("projected %s, want %s", data, want)
+	}
+}
+
+func TestProjectEmpty(t *testing.T) {
+	data, err := Project(newTestProjectionEconomyConfig(), nil)
+	if err != nil {
+		t.Fatalf("project failed: %v", err)
+	}
+	if len(data) == 0 || string(data) == `{}` {
+		t.Errorf("projected %s, want the whole config", data)
+	}
+}
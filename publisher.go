@@ -16,16 +16,31 @@ package hiro
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+// PublisherEventSchemaVersion is the version of the published event schema. It is bumped whenever the serialized
+// fields of events, or the metadata keys set on an event type, change in a way consumers may observe.
+//
+// Version 1 events contain the fields name, id, timestamp, metadata, value, and schema_version. Metadata keys are
+// specific to each event name and values are always strings.
+const PublisherEventSchemaVersion = 1
+
+// PublisherEventMetadataSchemaVersion is the reserved metadata key which carries the schema version on targets that
+// only accept the event metadata, such as Satori.
+const PublisherEventMetadataSchemaVersion = "hiro_schema_version"
+
 type PublisherEvent struct {
 	Name      string            `json:"name,omitempty"`
 	Id        string            `json:"id,omitempty"`
 	Timestamp int64             `json:"timestamp,omitempty"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
 	Value     string            `json:"value,omitempty"`
+	// The schema version the event conforms to, set to PublisherEventSchemaVersion when serialized if empty.
+	SchemaVersion int `json:"schema_version,omitempty"`
 
 	// The Hiro system that generated this event.
 	System System `json:"-"`
@@ -33,6 +48,34 @@ type PublisherEvent struct {
 	SourceId string `json:"-"`
 	// Source represents the configuration of the event source, such as an achievement config.
 	Source any `json:"-"`
+	// Actor represents who performed the operation which generated this event.
+	Actor *Actor `json:"-"`
+}
+
+// MarshalJSON serializes the event with the schema version always included, whether the event is marshaled by value
+// or by pointer.
+func (e PublisherEvent) MarshalJSON() ([]byte, error) {
+	type publisherEvent PublisherEvent
+	event := publisherEvent(e)
+	if event.SchemaVersion == 0 {
+		event.SchemaVersion = PublisherEventSchemaVersion
+	}
+	return json.Marshal(&event)
+}
+
+// SchemaMetadata returns a copy of the event metadata with the schema version added under
+// PublisherEventMetadataSchemaVersion.
+func (e *PublisherEvent) SchemaMetadata() map[string]string {
+	schemaVersion := e.SchemaVersion
+	if schemaVersion == 0 {
+		schemaVersion = PublisherEventSchemaVersion
+	}
+	metadata := make(map[string]string, len(e.Metadata)+1)
+	for k, v := range e.Metadata {
+		metadata[k] = v
+	}
+	metadata[PublisherEventMetadataSchemaVersion] = strconv.Itoa(schemaVersion)
+	return metadata
 }
 
 // The Publisher describes a service or similar target implementation that wishes to receive and process
@@ -0,0 +1,99 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+)
+
+func TestPublisherEventMarshalJSONSchemaVersion(t *testing.T) {
+	event := PublisherEvent{Name: "achievementClaimed", Id: "event", Metadata: map[string]string{"achievement_id": "first_win"}, System: &testSystem{}}
+
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{name: "value", value: event},
+		{name: "pointer", value: &event},
+		{name: "slice element", value: []PublisherEvent{event}},
+		{name: "pointer slice element", value: []*PublisherEvent{&event}},
+		{name: "struct field", value: struct{ Event PublisherEvent }{Event: event}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var decoded any
+			if err = json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			switch v := decoded.(type) {
+			case []any:
+				decoded = v[0]
+			case map[string]any:
+				if nested, ok := v["Event"]; ok {
+					decoded = nested
+				}
+			}
+			fields, ok := decoded.(map[string]any)
+			if !ok {
+				t.Fatalf("event serialized as %s", data)
+			}
+			if version := fields["schema_version"]; version != float64(PublisherEventSchemaVersion) {
+				t.Errorf("schema_version = %v, want %d in %s", version, PublisherEventSchemaVersion, data)
+			}
+			if _, found := fields["System"]; found {
+				t.Errorf("internal fields serialized in %s", data)
+			}
+		})
+	}
+
+	event.SchemaVersion = 2
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	decoded := &PublisherEvent{}
+	if err = json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.SchemaVersion != 2 {
+		t.Errorf("explicit schema_version = %d, want 2", decoded.SchemaVersion)
+	}
+}
+
+func TestPublisherEventSchemaMetadata(t *testing.T) {
+	event := &PublisherEvent{Name: "achievementClaimed", Metadata: map[string]string{"achievement_id": "first_win"}}
+
+	metadata := event.SchemaMetadata()
+	if metadata[PublisherEventMetadataSchemaVersion] != strconv.Itoa(PublisherEventSchemaVersion) {
+		t.Errorf("schema version metadata = %q, want %d", metadata[PublisherEventMetadataSchemaVersion], PublisherEventSchemaVersion)
+	}
+	if metadata["achievement_id"] != "first_win" {
+		t.Errorf("achievement_id metadata = %q, want first_win", metadata["achievement_id"])
+	}
+	if _, found := event.Metadata[PublisherEventMetadataSchemaVersion]; found {
+		t.Error("schema metadata modified the event metadata")
+	}
+
+	if metadata = (&PublisherEvent{SchemaVersion: 2}).SchemaMetadata(); metadata[PublisherEventMetadataSchemaVersion] != "2" {
+		t.Errorf("explicit schema version metadata = %q, want 2", metadata[PublisherEventMetadataSchemaVersion])
+	}
+}
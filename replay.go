@@ -0,0 +1,136 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var (
+	ErrReplayDetected        = runtime.NewError("request replayed", 6)            // ALREADY_EXISTS
+	ErrReplaySequenceMissing = runtime.NewError("request sequence missing", 3)    // INVALID_ARGUMENT
+	ErrReplayResyncInvalid   = runtime.NewError("replay resync token invalid", 3) // INVALID_ARGUMENT
+	ErrReplayResyncExpired   = runtime.NewError("replay resync token expired", 9) // FAILED_PRECONDITION
+)
+
+// The reserved top-level keys in an RPC payload which carry replay protection fields. They're removed from the payload
+// before it's decoded by the RPC.
+const (
+	// ReplayPayloadKeySequence is the client's sequence number for the RPC, which must be greater than the last one
+	// accepted for the user.
+	ReplayPayloadKeySequence = "hiro_sequence"
+	// ReplayPayloadKeyResyncToken is a token issued by ReplayResync which lets the client restart its sequence.
+	ReplayPayloadKeyResyncToken = "hiro_resync_token"
+)
+
+// BaseSystemConfigReplayProtection rejects client-computed results which are submitted again byte-for-byte, such as
+// stats updates or event leaderboard scores. The RPC wrapper enforces it for the configured RPCs, and persists the
+// high-water mark of the sequence numbers accepted for each user and RPC.
+//
+// A client which loses its sequence, such as after a reinstall, calls ReplayResync to get a single-use resync token.
+// Its next request to the RPC includes the token with any sequence, which is accepted as the new high-water mark.
+type BaseSystemConfigReplayProtection struct {
+	// The IDs of the RPCs which require a sequence, such as "RPC_ID_STATS_UPDATE".
+	RpcIds []string `json:"rpc_ids,omitempty"`
+	// How long a resync token is valid after it's issued, defaults to 300 seconds.
+	ResyncExpirySec int64 `json:"resync_expiry_sec,omitempty"`
+}
+
+// Enabled returns true if requests to the RPC must carry a sequence.
+func (c *BaseSystemConfigReplayProtection) Enabled(rpcID string) bool {
+	if c == nil {
+		return false
+	}
+	for _, id := range c.RpcIds {
+		if id == rpcID {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayState is the replay protection state persisted for a user and RPC.
+type ReplayState struct {
+	RpcId string `json:"rpc_id,omitempty"`
+	// The highest sequence accepted.
+	Sequence int64 `json:"sequence,omitempty"`
+	// The pending resync token, if one has been issued and not yet used.
+	ResyncToken         string `json:"resync_token,omitempty"`
+	ResyncExpiryTimeSec int64  `json:"resync_expiry_time_sec,omitempty"`
+}
+
+// Check validates the sequence and resync token of a request against the state, and returns the state to persist if
+// the request is accepted. A valid resync token accepts any sequence and is consumed.
+func (s *ReplayState) Check(sequence int64, resyncToken string, nowSec int64) (*ReplayState, error) {
+	next := &ReplayState{}
+	if s != nil {
+		*next = *s
+	}
+
+	if resyncToken != "" {
+		if next.ResyncToken == "" || resyncToken != next.ResyncToken {
+			return nil, ErrReplayResyncInvalid
+		}
+		if next.ResyncExpiryTimeSec <= nowSec {
+			return nil, ErrReplayResyncExpired
+		}
+		next.Sequence = sequence
+		next.ResyncToken = ""
+		next.ResyncExpiryTimeSec = 0
+		return next, nil
+	}
+
+	if sequence <= next.Sequence {
+		return nil, ErrReplayDetected
+	}
+	next.Sequence = sequence
+	return next, nil
+}
+
+// ReplayPayloadFields returns the replay protection fields set on an RPC payload, and the payload without them. It
+// returns ErrReplaySequenceMissing if the payload has no sequence.
+func ReplayPayloadFields(payload string) (sequence int64, resyncToken, remaining string, err error) {
+	if !strings.Contains(payload, `"`+ReplayPayloadKeySequence+`"`) {
+		return 0, "", payload, ErrReplaySequenceMissing
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return 0, "", payload, ErrPayloadDecode
+	}
+	raw, found := fields[ReplayPayloadKeySequence]
+	if !found {
+		return 0, "", payload, ErrReplaySequenceMissing
+	}
+	if err := json.Unmarshal(raw, &sequence); err != nil {
+		return 0, "", payload, ErrPayloadInvalid
+	}
+	if raw, found := fields[ReplayPayloadKeyResyncToken]; found {
+		if err := json.Unmarshal(raw, &resyncToken); err != nil {
+			return 0, "", payload, ErrPayloadInvalid
+		}
+	}
+	delete(fields, ReplayPayloadKeySequence)
+	delete(fields, ReplayPayloadKeyResyncToken)
+
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return 0, "", payload, ErrPayloadEncode
+	}
+	return sequence, resyncToken, string(stripped), nil
+}
@@ -16,28 +16,222 @@ package hiro
 
 import (
 	"context"
+	"math"
 
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+var ErrStatsVisibilityFixed = runtime.NewError("stat visibility cannot be changed", 3) // INVALID_ARGUMENT
+
+// The reserved additional properties set on stats which have a rolling window.
+const (
+	// StatPropertyWindow holds the values in the rolling window, oldest first.
+	StatPropertyWindow = "hiro_window"
+	// StatPropertyWindowAggregate holds the aggregate of the values in the rolling window.
+	StatPropertyWindowAggregate = "hiro_window_aggregate"
+	// StatPropertyEffectiveValue holds the value after diminishing returns past the stat's soft cap.
+	StatPropertyEffectiveValue = "hiro_effective_value"
+	// StatPropertyHidden is "true" on the owner's own public stats which they have hidden from other users.
+	StatPropertyHidden = "hiro_hidden"
+)
+
+// The visibility of a public stat.
+const (
+	// StatVisibilityPublic stats are always visible to other users. This is the default.
+	StatVisibilityPublic = "public"
+	// StatVisibilityOptional stats are visible to other users unless the owner hides them.
+	StatVisibilityOptional = "optional"
+)
+
+// The aggregates which can be computed over the values in a stat's rolling window.
+const (
+	StatWindowAggregateMean = "mean"
+	StatWindowAggregateSum  = "sum"
+	// StatWindowAggregateRate is the fraction of values in the window which are non-zero, such as a win rate where each
+	// value is the outcome of a match.
+	StatWindowAggregateRate = "rate"
+)
+
 // StatsConfig is the data definition for a StatsSystem type.
 type StatsConfig struct {
 	Whitelist    []string                    `json:"whitelist,omitempty"`
 	StatsPublic  map[string]*StatsConfigStat `json:"stats_public,omitempty"`
 	StatsPrivate map[string]*StatsConfigStat `json:"stats_private,omitempty"`
+	Sink         *StatsConfigSink            `json:"sink,omitempty"`
+}
+
+// StatsConfigSink configures the queue which buffers stat changes for the registered sinks. Changes are flushed
+// asynchronously so player requests never wait for a sink, and are dropped and counted when the queue is full.
+type StatsConfigSink struct {
+	// The maximum number of changes buffered, defaults to 10000.
+	QueueSize int `json:"queue_size,omitempty"`
+	// The maximum number of changes passed to a sink in one call, defaults to 100.
+	BatchSize int `json:"batch_size,omitempty"`
+	// How often buffered changes are flushed, defaults to 1000 milliseconds.
+	FlushIntervalMs int64 `json:"flush_interval_ms,omitempty"`
+}
+
+// The namespaces of the stats in a StatChange.
+const (
+	StatNamespacePublic  = "public"
+	StatNamespacePrivate = "private"
+)
+
+// StatChange is a single change to a user's stat made by a successful update.
+type StatChange struct {
+	UserId    string `json:"user_id,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Old       int64  `json:"old"`
+	New       int64  `json:"new"`
+	// The gameplay system which made the update, such as the stats system itself or a reward grant.
+	Source  SystemType `json:"source,omitempty"`
+	TimeSec int64      `json:"time_sec,omitempty"`
+}
+
+// A StatSink receives the changes made to stats, such as to export them to a data warehouse. Changes are delivered
+// in batches from a background flush, in the order they were made.
+//
+// StatSink implementations must safely handle concurrent calls, and handle any errors or retries internally.
+type StatSink interface {
+	SendStatChanges(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, changes []*StatChange)
 }
 
 type StatsConfigStat struct {
-	Value                int64                  `json:"value,omitempty"`
-	AdditionalProperties map[string]interface{} `json:"additional_properties,omitempty"`
+	Value                int64                   `json:"value,omitempty"`
+	AdditionalProperties map[string]interface{}  `json:"additional_properties,omitempty"`
+	Window               *StatsConfigStatWindow  `json:"window,omitempty"`
+	SoftCap              *StatsConfigStatSoftCap `json:"soft_cap,omitempty"`
+	// Whether the owner can hide a public stat from other users, one of "public" or "optional". Private stats are
+	// always private.
+	Visibility string `json:"visibility,omitempty" hirovalidate:"oneof=public|optional,policy=default"`
+}
+
+// StatVisible returns true if a user's public stat is visible to the viewer. Owners always see their own stats, and
+// other users only see a stat the owner has hidden if its visibility is not optional.
+func StatVisible(config *StatsConfigStat, hidden, owner bool) bool {
+	if owner || !hidden {
+		return true
+	}
+	return config == nil || config.Visibility != StatVisibilityOptional
+}
+
+// The curves which reduce the part of a stat's value above its soft cap.
+const (
+	// StatSoftCapCurveLinear counts each point above the cap as the factor, such as 0.5 for half a point.
+	StatSoftCapCurveLinear = "linear"
+	// StatSoftCapCurveSqrt counts the square root of the excess above the cap, multiplied by the factor.
+	StatSoftCapCurveSqrt = "sqrt"
+	// StatSoftCapCurveLog counts the natural logarithm of one plus the excess above the cap, multiplied by the factor.
+	StatSoftCapCurveLog = "log"
+)
+
+// StatsConfigStatSoftCap gives a stat diminishing returns past a soft cap to discourage grinding. The raw value is
+// stored unchanged, and the effective value is used where the stat counts towards leaderboards and achievements. The
+// cap can be set per user with a Personalizer.
+type StatsConfigStatSoftCap struct {
+	Cap int64 `json:"cap,omitempty"`
+	// The curve applied above the cap, one of "linear", "sqrt", or "log", defaults to "linear".
+	Curve string `json:"curve,omitempty" hirovalidate:"oneof=linear|sqrt|log,policy=default"`
+	// The multiplier of the curve, at most 1 so the effective value never exceeds the raw value. Defaults to 0.5 for
+	// the linear curve and 1 otherwise.
+	Factor float64 `json:"factor,omitempty" hirovalidate:"min=0,max=1,policy=clamp"`
+}
+
+// StatSoftCapValue returns the effective value of a stat with the diminishing returns of its soft cap applied. Values
+// at or below the cap, and stats without a soft cap, are returned unchanged. A factor above 1 is treated as 1, so the
+// effective value is never more than the raw value. The result is rounded down.
+func StatSoftCapValue(value int64, softCap *StatsConfigStatSoftCap) int64 {
+	if softCap == nil || value <= softCap.Cap {
+		return value
+	}
+	excess := float64(value - softCap.Cap)
+	factor := min(softCap.Factor, 1)
+	var reduced float64
+	switch softCap.Curve {
+	case StatSoftCapCurveSqrt:
+		if factor <= 0 {
+			factor = 1
+		}
+		reduced = math.Sqrt(excess) * factor
+	case StatSoftCapCurveLog:
+		if factor <= 0 {
+			factor = 1
+		}
+		reduced = math.Log1p(excess) * factor
+	default:
+		if factor <= 0 {
+			factor = 0.5
+		}
+		reduced = excess * factor
+	}
+	return softCap.Cap + int64(math.Floor(reduced))
+}
+
+// StatsConfigStatWindow keeps the most recent values of a stat, such as the outcomes of the last 20 matches, and
+// returns their aggregate alongside the lifetime value.
+type StatsConfigStatWindow struct {
+	Length    int    `json:"length,omitempty"`
+	Aggregate string `json:"aggregate,omitempty"`
+}
+
+// StatWindowPush adds a value to the end of a rolling window and evicts the oldest values beyond its length. A window
+// longer than the length, such as after the length is personalized, is truncated to the most recent values.
+func StatWindowPush(window []int64, value int64, length int) []int64 {
+	if length <= 0 {
+		return nil
+	}
+	window = append(window, value)
+	if len(window) > length {
+		window = append([]int64(nil), window[len(window)-length:]...)
+	}
+	return window
+}
+
+// StatWindowAggregateValue computes the aggregate of the values in a rolling window. An empty window aggregates to 0.
+func StatWindowAggregateValue(window []int64, aggregate string) float64 {
+	if len(window) == 0 {
+		return 0
+	}
+	var sum, nonZero int64
+	for _, value := range window {
+		sum += value
+		if value != 0 {
+			nonZero++
+		}
+	}
+	switch aggregate {
+	case StatWindowAggregateSum:
+		return float64(sum)
+	case StatWindowAggregateRate:
+		return float64(nonZero) / float64(len(window))
+	default:
+		return float64(sum) / float64(len(window))
+	}
 }
 
 type StatsSystem interface {
 	System
 
-	// List all private stats for one or more users.
+	// List all private stats for one or more users. Public stats which another user has hidden are omitted, except
+	// from the caller's own stats which are always returned in full. Any enrichment of leaderboard records with public
+	// stats respects the same overrides with StatVisible.
 	List(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, userIDs []string) (stats map[string]*StatList, err error)
 
-	// Update private stats for a particular user.
+	// Update private stats for a particular user. Updates made with a context tagged by WithTransaction are recorded so
+	// they can be rolled back.
 	Update(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, publicStats []*StatUpdate, privateStats []*StatUpdate) (stats *StatList, err error)
+
+	// SetHidden hides or shows the user's own public stats from other users. Only stats with optional visibility can be
+	// hidden, others fail with ErrStatsVisibilityFixed.
+	SetHidden(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, hidden map[string]bool) (stats *StatList, err error)
+
+	// AddStatSink registers a sink which receives every stat change made by successful updates.
+	AddStatSink(sink StatSink)
+
+	// StatSinkDropped returns the number of stat changes dropped because the sink queue was full.
+	StatSinkDropped() (dropped int64)
+
+	// RollbackTransaction reverses the stat updates made for the user in a transaction tagged with WithTransaction.
+	RollbackTransaction(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, transactionID string) (stats *StatList, contributions []*TransactionContribution, err error)
 }
@@ -0,0 +1,57 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"testing"
+)
+
+func TestStatSoftCapValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   int64
+		softCap *StatsConfigStatSoftCap
+		want    int64
+	}{
+		{name: "no soft cap", value: 500, want: 500},
+		{name: "below cap", value: 50, softCap: &StatsConfigStatSoftCap{Cap: 100}, want: 50},
+		{name: "at cap", value: 100, softCap: &StatsConfigStatSoftCap{Cap: 100}, want: 100},
+		{name: "linear default factor", value: 201, softCap: &StatsConfigStatSoftCap{Cap: 100}, want: 150},
+		{name: "linear factor", value: 200, softCap: &StatsConfigStatSoftCap{Cap: 100, Curve: StatSoftCapCurveLinear, Factor: 0.25}, want: 125},
+		{name: "sqrt", value: 200, softCap: &StatsConfigStatSoftCap{Cap: 100, Curve: StatSoftCapCurveSqrt}, want: 110},
+		{name: "sqrt factor", value: 200, softCap: &StatsConfigStatSoftCap{Cap: 100, Curve: StatSoftCapCurveSqrt, Factor: 0.5}, want: 105},
+		{name: "log", value: 200, softCap: &StatsConfigStatSoftCap{Cap: 100, Curve: StatSoftCapCurveLog}, want: 104},
+		{name: "linear factor above 1", value: 200, softCap: &StatsConfigStatSoftCap{Cap: 100, Factor: 3}, want: 200},
+		{name: "sqrt factor above 1", value: 101, softCap: &StatsConfigStatSoftCap{Cap: 100, Curve: StatSoftCapCurveSqrt, Factor: 5}, want: 101},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatSoftCapValue(tt.value, tt.softCap); got != tt.want {
+				t.Errorf("soft cap value = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatSoftCapValidate(t *testing.T) {
+	softCap := &StatsConfigStatSoftCap{Cap: 100, Curve: "cubic", Factor: 2}
+	if _, reject := PersonalizerValidate(softCap); reject {
+		t.Fatal("soft cap was rejected")
+	}
+	if softCap.Curve != "" || softCap.Factor != 1 {
+		t.Errorf("soft cap = %+v, want the default curve and the factor clamped to 1", softCap)
+	}
+}
@@ -20,11 +20,26 @@ import (
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
-var ErrStreakResetInvalid = runtime.NewError("streak reset schedule invalid", 13)
+var (
+	ErrStreakResetInvalid          = runtime.NewError("streak reset schedule invalid", 13) // INTERNAL
+	ErrStreakTimezoneInvalid       = runtime.NewError("streak timezone invalid", 3)        // INVALID_ARGUMENT
+	ErrStreakTimezoneChangeLimited = runtime.NewError("streak timezone change limited", 9) // FAILED_PRECONDITION
+)
 
 // StreaksConfig is the data definition for a StreaksSystem type.
 type StreaksConfig struct {
-	Streaks map[string]*StreaksConfigStreak `json:"streaks,omitempty"`
+	Streaks  map[string]*StreaksConfigStreak `json:"streaks,omitempty"`
+	Timezone *StreaksConfigTimezone          `json:"timezone,omitempty"`
+}
+
+// StreaksConfigTimezone computes streak reset boundaries in each user's own timezone rather than UTC.
+type StreaksConfigTimezone struct {
+	// The key in the user's account metadata which holds an IANA timezone name, such as "America/New_York".
+	MetadataKey string `json:"metadata_key,omitempty"`
+	// The IANA timezone used when the user has not set one, defaults to UTC.
+	Default string `json:"default,omitempty"`
+	// The minimum number of seconds between changes to a user's pinned timezone.
+	ChangeCooldownSec int64 `json:"change_cooldown_sec,omitempty"`
 }
 
 type StreaksConfigStreak struct {
@@ -40,6 +55,8 @@ type StreaksConfigStreak struct {
 	StartTimeSec         int64                        `json:"start_time_sec,omitempty"`
 	EndTimeSec           int64                        `json:"end_time_sec,omitempty"`
 	Disabled             bool                         `json:"disabled,omitempty"`
+	// If true the reset schedule is evaluated in the user's pinned timezone instead of UTC.
+	UserTimezone bool `json:"user_timezone,omitempty"`
 }
 
 type StreaksConfigStreakReward struct {
@@ -48,6 +65,16 @@ type StreaksConfigStreakReward struct {
 	Reward   *EconomyConfigReward `json:"reward,omitempty"`
 }
 
+// StreakPreview is the state a streak would be in after the user's next update, without any changes being made.
+type StreakPreview struct {
+	// The count the streak would have after its next update of one.
+	Count int64 `json:"count,omitempty"`
+	// True if the streak would be reset first because the user missed its reset window.
+	Reset bool `json:"reset,omitempty"`
+	// The rewards which would become claimable at the previewed count.
+	Rewards []*StreaksConfigStreakReward `json:"rewards,omitempty"`
+}
+
 type StreaksSystem interface {
 	System
 
@@ -57,12 +84,20 @@ type StreaksSystem interface {
 	// Update one or more streaks with the indicated counts for the given user.
 	Update(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, streakIDs map[string]int64) (streaks map[string]*Streak, err error)
 
+	// PreviewNext returns the rewards the user would receive from their next update to each streak, accounting for
+	// any reset due to a missed window. No state is changed.
+	PreviewNext(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, streakIDs []string) (previews map[string]*StreakPreview, err error)
+
 	// Claim rewards for one or more streaks for the given user.
 	Claim(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, streakIDs []string) (streaks map[string]*Streak, err error)
 
 	// Reset progress on selected streaks for the given user.
 	Reset(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, streakIDs []string) (streaks map[string]*Streak, err error)
 
+	// SetTimezone validates the IANA timezone and pins it for the given user, all streak reset boundaries for the user
+	// are then computed in that timezone. Changes are limited by the configured change cooldown.
+	SetTimezone(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, timezone string) (streaks map[string]*Streak, err error)
+
 	// SetOnClaimReward sets a custom reward function which will run after a streak's reward is rolled.
 	SetOnClaimReward(fn OnReward[*StreaksConfigStreak])
 }
@@ -21,9 +21,183 @@ import (
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+var (
+	ErrTeamsTournamentNotFound       = runtime.NewError("team tournament not found", 3)       // INVALID_ARGUMENT
+	ErrTeamsTournamentSeedInvalid    = runtime.NewError("team tournament seeding invalid", 3) // INVALID_ARGUMENT
+	ErrTeamsTournamentNotInBracket   = runtime.NewError("team not in tournament bracket", 3)  // INVALID_ARGUMENT
+	ErrTeamsTournamentTeamEliminated = runtime.NewError("team eliminated from tournament", 9) // FAILED_PRECONDITION
+	ErrTeamsPermissionDenied         = runtime.NewError("team permission denied", 7)          // PERMISSION_DENIED
+	ErrTeamsCapacityUpgradeNotFound  = runtime.NewError("team capacity upgrade not found", 3) // INVALID_ARGUMENT
+	ErrTeamsTreasuryInsufficient     = runtime.NewError("team treasury insufficient", 9)      // FAILED_PRECONDITION
+	ErrTeamsBroadcastLimited         = runtime.NewError("team broadcast limit reached", 9)    // FAILED_PRECONDITION
+	ErrTeamsSpendProposalNotFound    = runtime.NewError("team spend proposal not found", 3)   // INVALID_ARGUMENT
+	ErrTeamsSpendProposalClosed      = runtime.NewError("team spend proposal closed", 9)      // FAILED_PRECONDITION
+	ErrTeamsSpendSelfApproval        = runtime.NewError("team spend self approval", 7)        // PERMISSION_DENIED
+)
+
 // TeamsConfig is the data definition for a TeamsSystem type.
 type TeamsConfig struct {
-	MaxTeamSize int `json:"max_team_size,omitempty"`
+	MaxTeamSize int                               `json:"max_team_size,omitempty"`
+	Tournaments map[string]*TeamsConfigTournament `json:"tournaments,omitempty"`
+	// Capacity upgrade tiers in ascending order of capacity, each must be purchased after the one before it.
+	CapacityUpgrades []*TeamsConfigCapacityUpgrade `json:"capacity_upgrades,omitempty"`
+	Succession       *TeamsConfigSuccession        `json:"succession,omitempty"`
+	Broadcasts       *TeamsConfigBroadcasts        `json:"broadcasts,omitempty"`
+	SpendApproval    *TeamsConfigSpendApproval     `json:"spend_approval,omitempty"`
+}
+
+// TeamsConfigSpendApproval requires treasury spends above a threshold to be approved by other members before they're
+// executed. A spend which exceeds the threshold of any of its currencies creates a pending proposal.
+type TeamsConfigSpendApproval struct {
+	// The largest amount of each currency which can be spent without approval.
+	Thresholds map[string]int64 `json:"thresholds,omitempty"`
+	// The number of approvals needed to execute a proposal, defaults to 1.
+	RequiredApprovals int `json:"required_approvals,omitempty"`
+	// The group roles which can approve or reject proposals, such as 0 for superadmins and 1 for admins. Defaults to
+	// superadmins and admins.
+	ApproverRoles []int `json:"approver_roles,omitempty"`
+	// How long a proposal waits for approval before it expires.
+	ExpirySec int64 `json:"expiry_sec,omitempty"`
+}
+
+// RequiresApproval returns true if spending the currencies exceeds the threshold of any of them.
+func (c *TeamsConfigSpendApproval) RequiresApproval(currencies map[string]int64) bool {
+	if c == nil {
+		return false
+	}
+	for currencyID, amount := range currencies {
+		if threshold, found := c.Thresholds[currencyID]; found && amount > threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// The states of a team spend proposal.
+const (
+	TeamsSpendProposalPending  = "pending"
+	TeamsSpendProposalExecuted = "executed"
+	TeamsSpendProposalRejected = "rejected"
+	TeamsSpendProposalExpired  = "expired"
+)
+
+// TeamsSpendProposal is a treasury spend awaiting approval by the team's approvers.
+type TeamsSpendProposal struct {
+	Id         string           `json:"id,omitempty"`
+	TeamId     string           `json:"team_id,omitempty"`
+	ProposerId string           `json:"proposer_id,omitempty"`
+	Currencies map[string]int64 `json:"currencies,omitempty"`
+	Reason     string           `json:"reason,omitempty"`
+	Status     string           `json:"status,omitempty"`
+	// The user IDs of the members who approved or rejected the proposal.
+	Approvals      []string `json:"approvals,omitempty"`
+	Rejections     []string `json:"rejections,omitempty"`
+	CreateTimeSec  int64    `json:"create_time_sec,omitempty"`
+	ExpiryTimeSec  int64    `json:"expiry_time_sec,omitempty"`
+	ExecuteTimeSec int64    `json:"execute_time_sec,omitempty"`
+}
+
+// TeamsConfigBroadcasts allows team leaders to push an announcement to all members as a notification.
+type TeamsConfigBroadcasts struct {
+	// The maximum number of broadcasts each team can send per day, zero is unlimited.
+	MaxPerDay int `json:"max_per_day,omitempty"`
+	// The code of the Nakama notification sent to members.
+	NotificationCode int `json:"notification_code,omitempty"`
+}
+
+// TeamsMemberMetadataMuted is the reserved key in a member's team membership metadata which is "true" if they have
+// muted the team's broadcasts.
+const TeamsMemberMetadataMuted = "hiro_muted"
+
+// TeamsBroadcast is the delivery result of a team broadcast.
+type TeamsBroadcast struct {
+	TeamId  string `json:"team_id,omitempty"`
+	Sent    int    `json:"sent,omitempty"`
+	Skipped int    `json:"skipped,omitempty"`
+	TimeSec int64  `json:"time_sec,omitempty"`
+}
+
+// The orderings used to choose a new team leader from the active members.
+const (
+	// TeamsSuccessionOrderRole chooses the member with the highest group role, then the longest membership.
+	TeamsSuccessionOrderRole = "role"
+	// TeamsSuccessionOrderContribution chooses the member with the highest contribution, then the highest group role.
+	TeamsSuccessionOrderContribution = "contribution"
+)
+
+// TeamsConfigSuccession transfers leadership of a team automatically when its leader becomes inactive. The check is
+// made lazily when any member next accesses the team.
+type TeamsConfigSuccession struct {
+	// How long the leader must have no activity before leadership is transferred.
+	InactiveDurationSec int64 `json:"inactive_duration_sec,omitempty"`
+	// How long a member must have been active within to be chosen as the new leader.
+	ActiveDurationSec int64 `json:"active_duration_sec,omitempty"`
+	// How long after a transfer before another transfer can be made, so a returning leader does not cause thrashing.
+	GraceDurationSec int64  `json:"grace_duration_sec,omitempty"`
+	Order            string `json:"order,omitempty"`
+}
+
+// TeamsSuccession records an automatic transfer of a team's leadership.
+type TeamsSuccession struct {
+	TeamId         string `json:"team_id,omitempty"`
+	PreviousLeader string `json:"previous_leader,omitempty"`
+	NewLeader      string `json:"new_leader,omitempty"`
+	TimeSec        int64  `json:"time_sec,omitempty"`
+}
+
+// TeamsConfigCapacityUpgrade raises the maximum number of members of a team in exchange for currencies spent from
+// the team's treasury.
+type TeamsConfigCapacityUpgrade struct {
+	Capacity   int              `json:"capacity,omitempty"`
+	Currencies map[string]int64 `json:"currencies,omitempty"`
+}
+
+// TeamsConfigTournament is the definition of a single-elimination bracket played between teams.
+type TeamsConfigTournament struct {
+	Name                 string                                  `json:"name,omitempty"`
+	Description          string                                  `json:"description,omitempty"`
+	MaxTeams             int                                     `json:"max_teams,omitempty"`
+	RoundDurationSec     int64                                   `json:"round_duration_sec,omitempty"`
+	PlacementRewards     []*TeamsConfigTournamentPlacementReward `json:"placement_rewards,omitempty"`
+	AdditionalProperties map[string]string                       `json:"additional_properties,omitempty"`
+}
+
+// TeamsConfigTournamentPlacementReward is granted to each member of the teams which finish within the placement range.
+type TeamsConfigTournamentPlacementReward struct {
+	PlacementMin int                  `json:"placement_min,omitempty"`
+	PlacementMax int                  `json:"placement_max,omitempty"`
+	Reward       *EconomyConfigReward `json:"reward,omitempty"`
+}
+
+// TeamsTournament is the state of a tournament bracket.
+type TeamsTournament struct {
+	Id           string `json:"id,omitempty"`
+	TournamentId string `json:"tournament_id,omitempty"`
+	// The team IDs in seeding order.
+	Seeds        []string                `json:"seeds,omitempty"`
+	Rounds       []*TeamsTournamentRound `json:"rounds,omitempty"`
+	CurrentRound int                     `json:"current_round,omitempty"`
+	StartTimeSec int64                   `json:"start_time_sec,omitempty"`
+	EndTimeSec   int64                   `json:"end_time_sec,omitempty"`
+	// Final placements of each team by ID, only set for teams which have been eliminated or won.
+	Placements map[string]int `json:"placements,omitempty"`
+}
+
+// TeamsTournamentRound is one round of paired matches in a tournament bracket.
+type TeamsTournamentRound struct {
+	Matches    []*TeamsTournamentMatch `json:"matches,omitempty"`
+	EndTimeSec int64                   `json:"end_time_sec,omitempty"`
+}
+
+// TeamsTournamentMatch pairs two teams in a round, where an empty team ID indicates a bye for the other team.
+type TeamsTournamentMatch struct {
+	TeamIdA string `json:"team_id_a,omitempty"`
+	TeamIdB string `json:"team_id_b,omitempty"`
+	// Aggregated points scored by the members of each team in the round.
+	ScoreA int64 `json:"score_a,omitempty"`
+	ScoreB int64 `json:"score_b,omitempty"`
+	// The team which advances to the next round, set once the round deadline has passed.
+	WinnerId string `json:"winner_id,omitempty"`
 }
 
 // A TeamsSystem is a gameplay system which wraps the groups system in Nakama server.
@@ -39,6 +213,52 @@ type TeamsSystem interface {
 	// Search for teams based on given criteria.
 	Search(ctx context.Context, db *sql.DB, logger runtime.Logger, nk runtime.NakamaModule, req *TeamSearchRequest) (teams *TeamList, err error)
 
+	// CapacityUpgrade spends the cost of the capacity upgrade tier from the team's treasury and raises the group's max
+	// count to its capacity. Only team admins can purchase upgrades. The purchase is idempotent, if the team already
+	// has the tier's capacity the team is returned unchanged and nothing is spent.
+	CapacityUpgrade(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, teamID string, capacity int) (team *Team, err error)
+
+	// CheckSuccession transfers leadership of the team if its leader has been inactive for longer than configured,
+	// adding an entry to the team feed and notifying the previous and new leaders. The succession is nil if no transfer
+	// was made. It is called automatically when a member accesses the team.
+	CheckSuccession(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, teamID string) (succession *TeamsSuccession, err error)
+
+	// Broadcast sends an announcement as a notification to every current member of the team, except members who have
+	// muted the team, and records it in the team feed. Only team admins can broadcast, and the number of broadcasts per
+	// team each day is limited by config with ErrTeamsBroadcastLimited.
+	Broadcast(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, teamID, subject string, content map[string]any) (broadcast *TeamsBroadcast, err error)
+
+	// TreasurySpend spends currencies from the team's treasury. A spend above the configured approval threshold creates
+	// a pending proposal instead, which is returned, and is executed once enough approvals are made. Each step is
+	// recorded in the team feed and the audit trail with the actor from the context.
+	TreasurySpend(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, teamID string, currencies map[string]int64, reason string) (proposal *TeamsSpendProposal, err error)
+
+	// SpendProposalVote approves or rejects a pending spend proposal. Only members with an approver role can vote, and
+	// the proposer cannot approve their own proposal with ErrTeamsSpendSelfApproval. The spend is executed automatically
+	// when the approvals reach the required count, and a single rejection closes the proposal.
+	SpendProposalVote(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, teamID, proposalID string, approve bool) (proposal *TeamsSpendProposal, err error)
+
+	// SpendProposalList returns the team's spend proposals, including those closed recently. Pending proposals which
+	// have passed their expiry are expired first.
+	SpendProposalList(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, teamID string) (proposals []*TeamsSpendProposal, err error)
+
+	// SetMuted mutes or unmutes the team's broadcasts for the user, stored in their team membership metadata.
+	SetMuted(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, teamID string, muted bool) (err error)
+
+	// TournamentCreate builds a new tournament bracket from the team IDs given in seeding order. Byes are given to
+	// the highest seeds when the number of teams is not a power of two.
+	TournamentCreate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, tournamentID string, seedTeamIDs []string, startTimeSec int64) (tournament *TeamsTournament, err error)
+
+	// TournamentGet returns the current state of a tournament bracket. Rounds whose deadline has passed are
+	// evaluated first, advancing the higher-scoring team and granting placement rewards when the bracket completes.
+	TournamentGet(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, id string) (tournament *TeamsTournament, err error)
+
+	// TournamentScore adds points scored by a user to their team's total in the current round of a tournament.
+	TournamentScore(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, id string, score int64) (tournament *TeamsTournament, err error)
+
+	// SetOnTournamentReward sets a custom reward function which will run after a tournament placement reward is rolled.
+	SetOnTournamentReward(fn OnReward[*TeamsConfigTournament])
+
 	// WriteChatMessage sends a message to the user's team even when they're not connected on a realtime socket.
 	WriteChatMessage(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, req *TeamWriteChatMessageRequest) (resp *ChannelMessageAck, err error)
 }
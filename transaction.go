@@ -0,0 +1,56 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"context"
+
+	"github.com/heroiclabs/nakama-common/runtime"
+)
+
+var (
+	ErrTransactionNotFound   = runtime.NewError("transaction not found", 3)           // INVALID_ARGUMENT
+	ErrTransactionRolledBack = runtime.NewError("transaction already rolled back", 9) // FAILED_PRECONDITION
+)
+
+type transactionContextKey struct{}
+
+// WithTransaction returns a context which tags the progress contributions made with it, such as stat and achievement
+// updates from a match, with a transaction ID. Tagged contributions can later be reversed with RollbackTransaction if
+// the action which generated them is disputed.
+func WithTransaction(ctx context.Context, transactionID string) context.Context {
+	return context.WithValue(ctx, transactionContextKey{}, transactionID)
+}
+
+// TransactionFromContext returns the transaction ID set on the context with WithTransaction, or an empty string.
+func TransactionFromContext(ctx context.Context) string {
+	transactionID, _ := ctx.Value(transactionContextKey{}).(string)
+	return transactionID
+}
+
+// TransactionContribution is the progress contributed to a single stat or achievement by a tagged transaction.
+type TransactionContribution struct {
+	SystemType SystemType `json:"system_type"`
+	// The stat or achievement ID.
+	Id string `json:"id,omitempty"`
+	// True if the contribution was made to a public stat.
+	Public bool `json:"public,omitempty"`
+	// The change in value made by the contribution, which is subtracted when the transaction is rolled back.
+	Delta int64 `json:"delta,omitempty"`
+	// The stat operator used by the contribution, for example "set" contributions are restored to Previous.
+	Operator string `json:"operator,omitempty"`
+	Previous int64  `json:"previous,omitempty"`
+	TimeSec  int64  `json:"time_sec,omitempty"`
+}
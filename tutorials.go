@@ -20,6 +20,10 @@ import (
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+// TutorialsVariantKey is the key of the resolved variant in the additional properties of each tutorial returned, and
+// in the metadata of every published tutorials event, so funnels can be split by variant.
+const TutorialsVariantKey = "variant"
+
 // TutorialsConfig is the data definition for the TutorialsSystem type.
 type TutorialsConfig struct {
 	Tutorials map[string]*TutorialsConfigTutorial `json:"tutorials,omitempty"`
@@ -29,6 +33,9 @@ type TutorialsConfigTutorial struct {
 	StartStep            int               `json:"start_step,omitempty"`
 	MaxStep              int               `json:"max_step,omitempty"`
 	AdditionalProperties map[string]string `json:"additional_properties,omitempty"`
+	// The variant of the tutorial flow, such as for an A/B test, which personalizers may override per user. The
+	// variant is pinned for the user on first assignment so later changes do not switch flows mid-tutorial.
+	Variant string `json:"variant,omitempty"`
 }
 
 // The TutorialsSystem is a gameplay system which records progress made through tutorials.
@@ -50,7 +57,8 @@ type TutorialsSystem interface {
 	// Update modifies a tutorial by its ID to step through it for the user by ID.
 	Update(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, tutorialID string, step int) (tutorial map[string]*Tutorial, err error)
 
-	// Reset wipes all known state for the given tutorial identifier(s).
+	// Reset wipes all known state for the given tutorial identifier(s). The pinned variant is cleared so the user is
+	// assigned the currently configured variant when they next start the tutorial.
 	Reset(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, tutorialIDs []string) (tutorials map[string]*Tutorial, err error)
 
 	// SetOnStepCompleted registers a hook that fires on tutorial step completions.
@@ -20,6 +20,35 @@ import (
 	"github.com/heroiclabs/nakama-common/runtime"
 )
 
+var (
+	ErrUnlockablesChoiceSetNotFound = runtime.NewError("unlockables choice set not found", 3) // INVALID_ARGUMENT
+	ErrUnlockablesChoiceSetInvalid  = runtime.NewError("unlockables choice set invalid", 3)   // INVALID_ARGUMENT
+	ErrUnlockablesChoiceSetNoSlots  = runtime.NewError("not enough slots for choice set", 9)  // FAILED_PRECONDITION
+	ErrUnlockablesMaxLevel          = runtime.NewError("unlockable at max level", 9)          // FAILED_PRECONDITION
+	ErrUnlockablesUpgradeNotReady   = runtime.NewError("unlockable upgrade not ready", 9)     // FAILED_PRECONDITION
+	ErrUnlockablesNotDiscardable    = runtime.NewError("unlockable not discardable", 9)       // FAILED_PRECONDITION
+	ErrUnlockablesDiscardConfirm    = runtime.NewError("unlockable discard not confirmed", 9) // FAILED_PRECONDITION
+	ErrUnlockablesQueueFull         = runtime.NewError("unlockables queue full", 9)           // FAILED_PRECONDITION
+	ErrUnlockablesNotPausable       = runtime.NewError("unlockable not pausable", 9)          // FAILED_PRECONDITION
+	ErrUnlockablesPauseLimit        = runtime.NewError("unlockable pause limit reached", 9)   // FAILED_PRECONDITION
+	ErrUnlockablesPaused            = runtime.NewError("unlockable already paused", 9)        // FAILED_PRECONDITION
+	ErrUnlockablesNotPaused         = runtime.NewError("unlockable not paused", 9)            // FAILED_PRECONDITION
+)
+
+// UnlockablePropertyLevel is the reserved additional property which holds the current level of an unlockable instance
+// which has upgrade levels.
+const UnlockablePropertyLevel = "hiro_level"
+
+// The reserved additional properties set on listed unlockable instances which can be paused.
+const (
+	// UnlockablePropertyPaused is "true" while the unlockable's timer is paused.
+	UnlockablePropertyPaused = "hiro_paused"
+	// UnlockablePropertyPauseRemainingSec is the paused duration the unlockable has left in its budget.
+	UnlockablePropertyPauseRemainingSec = "hiro_pause_remaining_sec"
+	// UnlockablePropertyPauseRemainingCount is the number of pauses the unlockable has left, absent if not limited.
+	UnlockablePropertyPauseRemainingCount = "hiro_pause_remaining_count"
+)
+
 // UnlockablesConfig is the data definition for a UnlockablesSystem type.
 type UnlockablesConfig struct {
 	ActiveSlots      int                                     `json:"active_slots,omitempty"`
@@ -28,10 +57,30 @@ type UnlockablesConfig struct {
 	SlotCost         *UnlockablesConfigSlotCost              `json:"slot_cost,omitempty"`
 	Unlockables      map[string]*UnlockablesConfigUnlockable `json:"unlockables,omitempty"`
 	MaxQueuedUnlocks int                                     `json:"max_queued_unlocks,omitempty"`
+	ChoiceSets       *UnlockablesConfigChoiceSets            `json:"choice_sets,omitempty"`
+	ClaimHistory     *UnlockablesConfigClaimHistory          `json:"claim_history,omitempty"`
 
 	UnlockableProbabilities []string `json:"-"`
 }
 
+// UnlockablesConfigChoiceSets configures how sets of candidate unlockables offered to a player are resolved.
+type UnlockablesConfigChoiceSets struct {
+	// How long the player has to choose which candidates to keep.
+	DurationSec int64 `json:"duration_sec,omitempty"`
+	// The maximum number of candidates which can be offered in a single choice set.
+	MaxCandidates int `json:"max_candidates,omitempty"`
+	// An optional consolation reward granted for each candidate which is not kept, otherwise they are discarded.
+	DiscardReward *EconomyConfigReward `json:"discard_reward,omitempty"`
+}
+
+// UnlockablesConfigClaimHistory configures the per-user history of claimed unlockables kept for support.
+type UnlockablesConfigClaimHistory struct {
+	// The number of most recent claims kept for each user, older entries are removed.
+	MaxEntries int `json:"max_entries,omitempty"`
+	// The number of most recent claims returned to clients as recent openings, zero disables the client list.
+	RecentOpenings int `json:"recent_openings,omitempty"`
+}
+
 type UnlockablesConfigSlotCost struct {
 	Items      map[string]int64 `json:"items,omitempty"`
 	Currencies map[string]int64 `json:"currencies,omitempty"`
@@ -39,6 +88,7 @@ type UnlockablesConfigSlotCost struct {
 
 type UnlockablesConfigUnlockable struct {
 	Probability          int                                   `json:"probability,omitempty"`
+	Rarity               int                                   `json:"rarity,omitempty"`
 	Category             string                                `json:"category,omitempty"`
 	Cost                 *UnlockablesConfigUnlockableCost      `json:"cost,omitempty"`
 	CostUnitTimeSec      int                                   `json:"cost_unit_time_sec,omitempty"`
@@ -48,6 +98,123 @@ type UnlockablesConfigUnlockable struct {
 	Reward               *EconomyConfigReward                  `json:"reward,omitempty"`
 	WaitTimeSec          int                                   `json:"wait_time_sec,omitempty"`
 	AdditionalProperties map[string]string                     `json:"additional_properties,omitempty"`
+	// Optional levels the unlockable is upgraded through in place after its initial unlock completes.
+	Levels []*UnlockablesConfigUnlockableLevel `json:"levels,omitempty"`
+	// Optional prerequisite checked when the unlockable is started, which fails with a *PrerequisiteError if it is not
+	// met.
+	Prerequisite *Prerequisite `json:"prerequisite,omitempty"`
+	// Optional rules which allow the unlockable to be discarded before it's claimed, otherwise it can't be discarded.
+	Discard *UnlockablesConfigUnlockableDiscard `json:"discard,omitempty"`
+	// Optional rules which allow the unlockable's timer to be paused, otherwise it can't be paused.
+	Pause *UnlockablesConfigUnlockablePause `json:"pause,omitempty"`
+}
+
+// UnlockablesConfigUnlockablePause allows an active unlockable's timer to be paused, such as while the player is away
+// for a tournament. A pause which reaches the budget of paused time ends by itself.
+type UnlockablesConfigUnlockablePause struct {
+	// The maximum total time the unlockable can be paused for, zero for no limit.
+	MaxPausedSec int64 `json:"max_paused_sec,omitempty"`
+	// The maximum number of times the unlockable can be paused, zero for no limit.
+	MaxPauses int `json:"max_pauses,omitempty"`
+}
+
+// UnlockablePauseState tracks the paused time of an unlockable. Completion is delayed by the accumulated paused time,
+// so the unlockable's start and completion timestamps are never rewritten.
+type UnlockablePauseState struct {
+	// When the current pause started, zero if not paused.
+	PauseTimeSec int64 `json:"pause_time_sec,omitempty"`
+	// The total duration of the pauses which have ended.
+	PausedSec  int64 `json:"paused_sec,omitempty"`
+	PauseCount int   `json:"pause_count,omitempty"`
+}
+
+// Paused returns true if the unlockable is paused at the given time.
+func (s *UnlockablePauseState) Paused(config *UnlockablesConfigUnlockablePause, nowSec int64) bool {
+	if s == nil || s.PauseTimeSec == 0 {
+		return false
+	}
+	return config == nil || config.MaxPausedSec <= 0 || s.PausedSec+max(nowSec-s.PauseTimeSec, 0) < config.MaxPausedSec
+}
+
+// TotalPausedSec returns the total time the unlockable has been paused by the given time, including any current pause,
+// limited to the budget. Every timestamp derived from the unlock, such as its projected completion and the projected
+// starts of queued unlocks after it, is delayed by this duration.
+func (s *UnlockablePauseState) TotalPausedSec(config *UnlockablesConfigUnlockablePause, nowSec int64) int64 {
+	if s == nil {
+		return 0
+	}
+	total := s.PausedSec
+	if s.PauseTimeSec > 0 && nowSec > s.PauseTimeSec {
+		total += nowSec - s.PauseTimeSec
+	}
+	if config != nil && config.MaxPausedSec > 0 {
+		total = min(total, config.MaxPausedSec)
+	}
+	return total
+}
+
+// RemainingSec returns the paused time left in the budget, or -1 if the paused time is not limited.
+func (s *UnlockablePauseState) RemainingSec(config *UnlockablesConfigUnlockablePause, nowSec int64) int64 {
+	if config == nil || config.MaxPausedSec <= 0 {
+		return -1
+	}
+	return max(config.MaxPausedSec-s.TotalPausedSec(config, nowSec), 0)
+}
+
+// Pause starts a pause at the given time. It returns ErrUnlockablesNotPausable if the unlockable can't be paused,
+// ErrUnlockablesPaused if it's already paused, and ErrUnlockablesPauseLimit if no pauses or paused time are left. As
+// with the other methods a nil state is never paused, and since it can't record a pause ErrUnlockablesNotPausable is
+// returned for it too.
+func (s *UnlockablePauseState) Pause(config *UnlockablesConfigUnlockablePause, nowSec int64) error {
+	if s == nil || config == nil {
+		return ErrUnlockablesNotPausable
+	}
+	if s.Paused(config, nowSec) {
+		return ErrUnlockablesPaused
+	}
+	// End a pause which has used up the budget by itself.
+	s.end(config, nowSec)
+	if (config.MaxPauses > 0 && s.PauseCount >= config.MaxPauses) || s.RemainingSec(config, nowSec) == 0 {
+		return ErrUnlockablesPauseLimit
+	}
+	s.PauseTimeSec = nowSec
+	s.PauseCount++
+	return nil
+}
+
+// Resume ends the current pause at the given time, or returns ErrUnlockablesNotPaused.
+func (s *UnlockablePauseState) Resume(config *UnlockablesConfigUnlockablePause, nowSec int64) error {
+	if !s.Paused(config, nowSec) {
+		s.end(config, nowSec)
+		return ErrUnlockablesNotPaused
+	}
+	s.end(config, nowSec)
+	return nil
+}
+
+func (s *UnlockablePauseState) end(config *UnlockablesConfigUnlockablePause, nowSec int64) {
+	if s == nil || s.PauseTimeSec == 0 {
+		return
+	}
+	s.PausedSec = s.TotalPausedSec(config, nowSec)
+	s.PauseTimeSec = 0
+}
+
+// UnlockablesConfigUnlockableDiscard allows an unlockable which has not been started to be discarded to free its slot.
+type UnlockablesConfigUnlockableDiscard struct {
+	// If true the unlockable may also be discarded while it's in the unlock queue.
+	Queued bool `json:"queued,omitempty"`
+	// If true the unlockable may also be discarded while it's unlocking, forfeiting the time elapsed.
+	Active bool `json:"active,omitempty"`
+	// An optional compensation reward granted when the unlockable is discarded.
+	Reward *EconomyConfigReward `json:"reward,omitempty"`
+}
+
+// UnlockablesConfigUnlockableLevel is an upgrade level of an unlockable, with its own timer, cost, and reward.
+type UnlockablesConfigUnlockableLevel struct {
+	WaitTimeSec int                                   `json:"wait_time_sec,omitempty"`
+	StartCost   *UnlockablesConfigUnlockableStartCost `json:"start_cost,omitempty"`
+	Reward      *EconomyConfigReward                  `json:"reward,omitempty"`
 }
 
 type UnlockablesConfigUnlockableCost struct {
@@ -60,9 +227,46 @@ type UnlockablesConfigUnlockableStartCost struct {
 	Currencies map[string]int64 `json:"currencies,omitempty"`
 }
 
+// UnlockablesChoiceSet is a pending set of candidate unlockables from which a player picks the ones to keep.
+type UnlockablesChoiceSet struct {
+	Id string `json:"id,omitempty"`
+	// The unlockable IDs offered, candidates are chosen by their index in this list.
+	Candidates []string `json:"candidates,omitempty"`
+	// The reason or source the choice set was created from, such as a match ID.
+	Source        string `json:"source,omitempty"`
+	CreateTimeSec int64  `json:"create_time_sec,omitempty"`
+	// When the choice set is automatically resolved by keeping the highest rarity candidates.
+	ExpireTimeSec int64 `json:"expire_time_sec,omitempty"`
+}
+
+// UnlockablesClaimHistoryEntry records the lifecycle of an unlockable from being started to being claimed.
+type UnlockablesClaimHistoryEntry struct {
+	UnlockableId    string `json:"unlockable_id,omitempty"`
+	InstanceId      string `json:"instance_id,omitempty"`
+	StartTimeSec    int64  `json:"start_time_sec,omitempty"`
+	CompleteTimeSec int64  `json:"complete_time_sec,omitempty"`
+	ClaimTimeSec    int64  `json:"claim_time_sec,omitempty"`
+	// Set instead of the claim time if the unlockable was discarded.
+	DiscardTimeSec int64 `json:"discard_time_sec,omitempty"`
+	// The speedups applied to the unlockable and what they cost, not included in recent openings.
+	Speedups []*UnlockablesClaimHistorySpeedup `json:"speedups,omitempty"`
+	// The reward contents granted when the unlockable was claimed.
+	Reward *Reward `json:"reward,omitempty"`
+}
+
+// UnlockablesClaimHistorySpeedup is a purchase which reduced the remaining time of an unlockable.
+type UnlockablesClaimHistorySpeedup struct {
+	TimeSec    int64            `json:"time_sec,omitempty"`
+	ReducedSec int64            `json:"reduced_sec,omitempty"`
+	Currencies map[string]int64 `json:"currencies,omitempty"`
+	Items      map[string]int64 `json:"items,omitempty"`
+}
+
 // The UnlockablesSystem is a gameplay system which provides slots to store rewards which can be unlocked over time.
 type UnlockablesSystem interface {
 	System
+	// ResolveCost resolves the cost of EconomyCostOperationUnlockableSpeedup operations for cost previews.
+	EconomyCostResolver
 
 	// Create will place a new unlockable into a slot either randomly, by ID, or optionally using a custom configuration.
 	Create(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, unlockableID string, unlockableConfig *UnlockablesConfigUnlockable) (unlockables *UnlockablesList, err error)
@@ -79,6 +283,17 @@ type UnlockablesSystem interface {
 	// PurchaseUnlock will immediately unlock an unlockable with the specified instance ID for a user.
 	PurchaseUnlock(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, instanceID string) (unlockables *UnlockablesList, err error)
 
+	// Pause pauses the timer of an active unlockable by instance ID. Its completion, and the projected starts of any
+	// queued unlocks, are delayed by the time it stays paused.
+	Pause(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, instanceID string) (unlockables *UnlockablesList, err error)
+
+	// Resume resumes the timer of a paused unlockable by instance ID.
+	Resume(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, instanceID string) (unlockables *UnlockablesList, err error)
+
+	// Upgrade charges the start cost of the next level of an unlockable by instance ID and starts its timer. The
+	// previous level's unlock must be complete, and the current level is tracked in the instance's additional properties.
+	Upgrade(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, instanceID string) (unlockables *UnlockablesList, err error)
+
 	// PurchaseSlot will create a new slot for a user by ID.
 	PurchaseSlot(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (unlockables *UnlockablesList, err error)
 
@@ -86,6 +301,8 @@ type UnlockablesSystem interface {
 	Claim(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, instanceID string) (reward *UnlockablesReward, err error)
 
 	// QueueAdd adds one or more unlockable instance IDs to the queue to be unlocked as soon as an active slot is available.
+	// When an active unlock completes the next queued instance is started automatically. The queue is returned in the
+	// queued unlocks of the list, and adding beyond the max queued unlocks fails with ErrUnlockablesQueueFull.
 	QueueAdd(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, instanceIDs []string) (unlockables *UnlockablesList, err error)
 
 	// QueueRemove removes one or more unlockable instance IDs from the unlock queue, unless they have started unlocking already.
@@ -94,6 +311,31 @@ type UnlockablesSystem interface {
 	// QueueSet replaces the entirety of the queue with the specified instance IDs, or wipes the queue if no instance IDs are given.
 	QueueSet(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, instanceIDs []string) (unlockables *UnlockablesList, err error)
 
+	// Discard removes an unclaimed unlockable by instance ID to free its slot, if its config allows it in its current
+	// state, and grants any compensation reward in the same write. The unlock queue advances into the freed slot, and
+	// the discard is recorded in the claim history. Discarding an unlockable which is unlocking forfeits the elapsed
+	// time and fails with ErrUnlockablesDiscardConfirm unless confirmForfeit is true.
+	Discard(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, instanceID string, confirmForfeit bool) (unlockables *UnlockablesList, reward *Reward, err error)
+
+	// ChoiceSetCreate offers multiple candidate unlockables to a user, who may choose which to keep in their
+	// available slots before the choice set expires.
+	ChoiceSetCreate(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, source string, unlockableIDs []string) (choiceSet *UnlockablesChoiceSet, err error)
+
+	// ChoiceSetList returns the pending choice sets for a user along with their deadlines. Any expired choice sets are
+	// resolved first by keeping the highest rarity candidates which fit into the available slots.
+	ChoiceSetList(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (choiceSets []*UnlockablesChoiceSet, unlockables *UnlockablesList, err error)
+
+	// ChoiceSetResolve keeps the candidates chosen by index in the available slots for a user, the remaining candidates
+	// are discarded or converted into the configured consolation reward.
+	ChoiceSetResolve(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID, choiceSetID string, candidateIndexes []int) (unlockables *UnlockablesList, reward *Reward, err error)
+
+	// ClaimHistory returns the most recent claims for a user, newest first. History entries are written in the same
+	// storage write as the claim.
+	ClaimHistory(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string, limit int) (history []*UnlockablesClaimHistoryEntry, err error)
+
+	// RecentOpenings returns a trimmed list of the most recent claims for a user which is suitable for clients.
+	RecentOpenings(ctx context.Context, logger runtime.Logger, nk runtime.NakamaModule, userID string) (history []*UnlockablesClaimHistoryEntry, err error)
+
 	// SetOnClaimReward sets a custom reward function which will run after an unlockable's reward is rolled.
 	SetOnClaimReward(fn OnReward[*UnlockablesConfigUnlockable])
 }
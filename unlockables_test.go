@@ -0,0 +1,119 @@
+// Copyright 2024 Heroic Labs & Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiro
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnlockablePauseStatePauseResume(t *testing.T) {
+	config := &UnlockablesConfigUnlockablePause{}
+	state := &UnlockablePauseState{}
+
+	if err := state.Pause(config, 100); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if !state.Paused(config, 150) {
+		t.Error("state is not paused")
+	}
+	if err := state.Pause(config, 150); !errors.Is(err, ErrUnlockablesPaused) {
+		t.Errorf("second pause returned %v, want %v", err, ErrUnlockablesPaused)
+	}
+	if total := state.TotalPausedSec(config, 150); total != 50 {
+		t.Errorf("total paused = %d during a pause, want 50", total)
+	}
+	if err := state.Resume(config, 200); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if err := state.Resume(config, 250); !errors.Is(err, ErrUnlockablesNotPaused) {
+		t.Errorf("second resume returned %v, want %v", err, ErrUnlockablesNotPaused)
+	}
+
+	if err := state.Pause(config, 300); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if err := state.Resume(config, 330); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if total := state.TotalPausedSec(config, 1000); total != 130 || state.PauseCount != 2 {
+		t.Errorf("total paused = %d after %d pauses, want 130 after 2", total, state.PauseCount)
+	}
+	if remaining := state.RemainingSec(config, 1000); remaining != -1 {
+		t.Errorf("remaining = %d, want -1 for an unlimited budget", remaining)
+	}
+}
+
+func TestUnlockablePauseStateLimits(t *testing.T) {
+	config := &UnlockablesConfigUnlockablePause{MaxPausedSec: 100, MaxPauses: 3}
+	state := &UnlockablePauseState{}
+
+	if err := state.Pause(config, 1000); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if err := state.Resume(config, 1040); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if remaining := state.RemainingSec(config, 1040); remaining != 60 {
+		t.Errorf("remaining = %d, want 60", remaining)
+	}
+
+	// A pause which uses up the budget ends by itself.
+	if err := state.Pause(config, 1100); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if state.Paused(config, 1200) {
+		t.Error("state is paused beyond the budget")
+	}
+	if total := state.TotalPausedSec(config, 1500); total != 100 {
+		t.Errorf("total paused = %d, want it limited to 100", total)
+	}
+	if err := state.Resume(config, 1500); !errors.Is(err, ErrUnlockablesNotPaused) {
+		t.Errorf("resume after the budget returned %v, want %v", err, ErrUnlockablesNotPaused)
+	}
+	if err := state.Pause(config, 1600); !errors.Is(err, ErrUnlockablesPauseLimit) {
+		t.Errorf("pause without budget returned %v, want %v", err, ErrUnlockablesPauseLimit)
+	}
+
+	config = &UnlockablesConfigUnlockablePause{MaxPauses: 1}
+	state = &UnlockablePauseState{}
+	if err := state.Pause(config, 1000); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if err := state.Resume(config, 1010); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if err := state.Pause(config, 1020); !errors.Is(err, ErrUnlockablesPauseLimit) {
+		t.Errorf("pause beyond the max pauses returned %v, want %v", err, ErrUnlockablesPauseLimit)
+	}
+}
+
+func TestUnlockablePauseStateNotPausable(t *testing.T) {
+	if err := (&UnlockablePauseState{}).Pause(nil, 0); !errors.Is(err, ErrUnlockablesNotPausable) {
+		t.Errorf("pause without config returned %v, want %v", err, ErrUnlockablesNotPausable)
+	}
+
+	var state *UnlockablePauseState
+	config := &UnlockablesConfigUnlockablePause{MaxPausedSec: 100}
+	if err := state.Pause(config, 0); !errors.Is(err, ErrUnlockablesNotPausable) {
+		t.Errorf("pause of a nil state returned %v, want %v", err, ErrUnlockablesNotPausable)
+	}
+	if err := state.Resume(config, 0); !errors.Is(err, ErrUnlockablesNotPaused) {
+		t.Errorf("resume of a nil state returned %v, want %v", err, ErrUnlockablesNotPaused)
+	}
+	if state.Paused(config, 0) || state.TotalPausedSec(config, 0) != 0 || state.RemainingSec(config, 0) != 100 {
+		t.Error("nil state is not treated as never paused")
+	}
+}